@@ -0,0 +1,153 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"sort"
+)
+
+/*
+Alphabet returns every rune the generator could ever emit, sorted.
+
+It is computed from the expression alone: runes produced by a CaptureGroupHandler other than the default are not
+included, and neither are ExcludeRunes, nor the runes of sub-expressions generating nothing once they are removed.
+Digits from character classes are reported in the configured DigitScript. Note that the alphabet of
+an expression containing "." or a negated class covers most of Unicode. Scheduled generators return the runes of
+all the generators they delegate to.
+*/
+func (gen *internalGenerator) Alphabet() []rune {
+	var runes []rune
+	for _, r := range gen.alphabetRanges() {
+		for c := r.Start; c < r.Start+rune(r.Size); c++ {
+			runes = append(runes, c)
+		}
+	}
+	return runes
+}
+
+// alphabetRanges returns the sorted, non-overlapping ranges of runes the generator could emit.
+func (gen *internalGenerator) alphabetRanges() []tCharClassRange {
+	if gen.regexp == nil {
+		return scheduledAlphabet(gen.scheduled)
+	}
+	regexp := gen.regexp
+	if gen.args != nil && gen.args.allowed != nil {
+		// gen.regexp is the expression before ExcludeRunes were removed, which may have runes it never generates.
+		regexp = excludeRunes(regexp, gen.args.allowed, map[*syntax.Regexp]*syntax.Regexp{})
+	}
+	ranges := collectAlphabet(regexp.Simplify(), gen.args, nil)
+	return mergeCharClassRanges(ranges)
+}
+
+func collectAlphabet(regexp *syntax.Regexp, args *GeneratorArgs, ranges []tCharClassRange) []tCharClassRange {
+	if unmatchable(regexp) != nil {
+		// Nothing is generated from it, e.g. from the "x" of `[ab]x` once 'a' and 'b' are excluded.
+		return ranges
+	}
+	switch regexp.Op {
+	case syntax.OpLiteral:
+		for _, r := range regexp.Rune {
+			if _, ok := backreferenceIndex(r); ok && args.backreferences {
+				// The group's runes are collected from the group itself.
+				continue
+			}
+			if regexp.Flags&syntax.FoldCase != 0 {
+				for _, variant := range caseVariants(r) {
+					ranges = append(ranges, tCharClassRange{Start: variant, Size: 1})
+				}
+				continue
+			}
+			ranges = append(ranges, tCharClassRange{Start: r, Size: 1})
+		}
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		ranges = appendClassAlphabet(ranges, generatedClass(regexp, args), args)
+	case syntax.OpRepeat:
+		if regexp.Max == 0 {
+			break
+		}
+		fallthrough
+	default:
+		for _, sub := range regexp.Sub {
+			ranges = collectAlphabet(sub, args, ranges)
+		}
+	}
+	return ranges
+}
+
+// appendClassAlphabet appends the ranges of class, with digits converted to args.DigitScript.
+func appendClassAlphabet(ranges []tCharClassRange, class *tCharClass, args *GeneratorArgs) []tCharClassRange {
+	if class == nil {
+		return ranges
+	}
+	for _, r := range class.Ranges {
+		end := r.Start + rune(r.Size) - 1
+		if args.DigitScript == ASCIIDigits || end < '0' || r.Start > '9' {
+			ranges = append(ranges, r)
+			continue
+		}
+
+		// Split out the digits, which are generated in a different script.
+		digitsStart, digitsEnd := maxRune(r.Start, '0'), minRune(end, '9')
+		if r.Start < digitsStart {
+			ranges = append(ranges, newCharClassRange(r.Start, digitsStart-1))
+		}
+		ranges = append(ranges, newCharClassRange(args.DigitScript.convert(digitsStart), args.DigitScript.convert(digitsEnd)))
+		if end > digitsEnd {
+			ranges = append(ranges, newCharClassRange(digitsEnd+1, end))
+		}
+	}
+	return ranges
+}
+
+// mergeCharClassRanges sorts ranges and merges the ones that overlap or touch.
+func mergeCharClassRanges(ranges []tCharClassRange) []tCharClassRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	var merged []tCharClassRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			lastEnd := last.Start + rune(last.Size)
+			if r.Start <= lastEnd {
+				if end := r.Start + rune(r.Size); end > lastEnd {
+					last.Size = int32(end - last.Start)
+				}
+				continue
+			}
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+func minRune(a, b rune) rune {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxRune(a, b rune) rune {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+	"unicode"
+)
+
+func TestAlphabet(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Literals and classes", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("[a-c]X[0-9]", nil)
+		if actual := string(generator.Alphabet()); actual != "0123456789Xabc" {
+			t.Fatalf("wrong alphabet: %q", actual)
+		}
+	})
+
+	t.Run("Merges overlaps and skips empty repeats", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("(ab|bc)*[a-d]z{0}", &GeneratorArgs{Flags: syntax.Perl})
+		if actual := string(generator.Alphabet()); actual != "abcd" {
+			t.Fatalf("wrong alphabet: %q", actual)
+		}
+	})
+
+	t.Run("Uses the digit script", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("[/-1]5", &GeneratorArgs{DigitScript: ArabicIndicDigits})
+		if actual := string(generator.Alphabet()); actual != "/5٠١" {
+			t.Fatalf("wrong alphabet: %q", actual)
+		}
+	})
+
+	t.Run("Leaves out excluded runes", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator("[ab]x|c[a-e]", &GeneratorArgs{ExcludeRunes: []rune{'a', 'b'}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := string(generator.Alphabet()); actual != "cde" {
+			t.Fatalf("wrong alphabet: %q", actual)
+		}
+	})
+
+	t.Run("Dot excludes newline", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(".", nil)
+		alphabet := generator.Alphabet()
+		for _, r := range alphabet {
+			if r == '\n' || r > unicode.MaxRune {
+				t.Fatalf("should not contain %U", r)
+			}
+		}
+		if alphabet[len(alphabet)-1] != unicode.MaxRune {
+			t.Fatal("should contain the maximum rune")
+		}
+	})
+}
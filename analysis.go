@@ -0,0 +1,251 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+)
+
+// captureNesting returns the maximum number of capture groups nested inside each other in regexp.
+func captureNesting(regexp *syntax.Regexp) int {
+	max := 0
+	for _, sub := range regexp.Sub {
+		if n := captureNesting(sub); n > max {
+			max = n
+		}
+	}
+	if regexp.Op == syntax.OpCapture {
+		max++
+	}
+	return max
+}
+
+// expressionDepth returns the number of nodes on the longest path from regexp to a leaf of its syntax tree.
+func expressionDepth(regexp *syntax.Regexp) int {
+	max := 0
+	for _, sub := range regexp.Sub {
+		if n := expressionDepth(sub); n > max {
+			max = n
+		}
+	}
+	return max + 1
+}
+
+// unmatchable returns a subexpression of regexp that matches no string and makes regexp match none either, e.g.
+// the empty class in `a[^\x00-\x{10FFFF}]`, or nil if there is none. Subexpressions that match nothing but may be
+// skipped, like `[^\x00-\x{10FFFF}]*` or a branch of an alternation, don't count.
+func unmatchable(regexp *syntax.Regexp) *syntax.Regexp {
+	switch regexp.Op {
+	case syntax.OpNoMatch:
+		return regexp
+	case syntax.OpCharClass:
+		if len(regexp.Rune) == 0 {
+			return regexp
+		}
+	case syntax.OpCapture, syntax.OpPlus:
+		return unmatchable(regexp.Sub[0])
+	case syntax.OpRepeat:
+		if regexp.Min > 0 {
+			return unmatchable(regexp.Sub[0])
+		}
+	case syntax.OpConcat:
+		for _, sub := range regexp.Sub {
+			if u := unmatchable(sub); u != nil {
+				return u
+			}
+		}
+	case syntax.OpAlternate:
+		for _, sub := range regexp.Sub {
+			if unmatchable(sub) == nil {
+				return nil
+			}
+		}
+		return regexp
+	}
+	return nil
+}
+
+// satisfiable reports whether any string can match regexp. It only checks that anchors are not misplaced in
+// sequences, so it may return true for expressions that can never match, but never false for ones that can.
+func satisfiable(regexp *syntax.Regexp) bool {
+	switch regexp.Op {
+	case syntax.OpNoMatch:
+		return false
+	case syntax.OpCapture, syntax.OpPlus:
+		return satisfiable(regexp.Sub[0])
+	case syntax.OpRepeat:
+		return regexp.Min == 0 || satisfiable(regexp.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range regexp.Sub {
+			if satisfiable(sub) {
+				return true
+			}
+		}
+		return false
+	case syntax.OpConcat:
+		return concatSatisfiable(flattenConcat(regexp))
+	}
+	return true
+}
+
+// flattenConcat returns the sequence of expressions matched by regexp, looking through capture groups.
+func flattenConcat(regexp *syntax.Regexp) []*syntax.Regexp {
+	switch regexp.Op {
+	case syntax.OpCapture:
+		return flattenConcat(regexp.Sub[0])
+	case syntax.OpConcat:
+		var seq []*syntax.Regexp
+		for _, sub := range regexp.Sub {
+			seq = append(seq, flattenConcat(sub)...)
+		}
+		return seq
+	}
+	return []*syntax.Regexp{regexp}
+}
+
+func concatSatisfiable(seq []*syntax.Regexp) bool {
+	for i, regexp := range seq {
+		if !satisfiable(regexp) {
+			return false
+		}
+
+		switch regexp.Op {
+		case syntax.OpBeginText:
+			for _, before := range seq[:i] {
+				if !canBeEmpty(before) {
+					return false
+				}
+			}
+		case syntax.OpEndText:
+			for _, after := range seq[i+1:] {
+				if !canBeEmpty(after) {
+					return false
+				}
+			}
+		case syntax.OpBeginLine:
+			if !canReachNewline(reverse(seq[:i]), true) {
+				return false
+			}
+		case syntax.OpEndLine:
+			if !canReachNewline(seq[i+1:], false) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// canReachNewline reports whether seq can match the empty string or a string beginning with a newline.
+// If fromEnd is true, seq is in reverse order and the newline is looked for at the end.
+func canReachNewline(seq []*syntax.Regexp, fromEnd bool) bool {
+	for _, regexp := range seq {
+		if canTouchNewline(regexp, fromEnd) {
+			return true
+		}
+		if !canBeEmpty(regexp) {
+			return false
+		}
+	}
+	return true
+}
+
+// canTouchNewline reports whether regexp can match a string beginning (or ending, if fromEnd) with a newline.
+func canTouchNewline(regexp *syntax.Regexp, fromEnd bool) bool {
+	switch regexp.Op {
+	case syntax.OpLiteral:
+		if len(regexp.Rune) == 0 {
+			return false
+		}
+		if fromEnd {
+			return regexp.Rune[len(regexp.Rune)-1] == '\n'
+		}
+		return regexp.Rune[0] == '\n'
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(regexp.Rune); i += 2 {
+			if regexp.Rune[i] <= '\n' && '\n' <= regexp.Rune[i+1] {
+				return true
+			}
+		}
+		return false
+	case syntax.OpAnyChar:
+		return true
+	case syntax.OpCapture, syntax.OpStar, syntax.OpPlus, syntax.OpQuest:
+		return canTouchNewline(regexp.Sub[0], fromEnd)
+	case syntax.OpRepeat:
+		return regexp.Max != 0 && canTouchNewline(regexp.Sub[0], fromEnd)
+	case syntax.OpAlternate:
+		for _, sub := range regexp.Sub {
+			if canTouchNewline(sub, fromEnd) {
+				return true
+			}
+		}
+		return false
+	case syntax.OpConcat:
+		seq := regexp.Sub
+		if fromEnd {
+			seq = reverse(seq)
+		}
+		for _, sub := range seq {
+			if canTouchNewline(sub, fromEnd) {
+				return true
+			}
+			if !canBeEmpty(sub) {
+				return false
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// canBeEmpty reports whether regexp can match the empty string.
+func canBeEmpty(regexp *syntax.Regexp) bool {
+	switch regexp.Op {
+	case syntax.OpLiteral:
+		return len(regexp.Rune) == 0
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL, syntax.OpNoMatch:
+		return false
+	case syntax.OpCapture, syntax.OpPlus:
+		return canBeEmpty(regexp.Sub[0])
+	case syntax.OpRepeat:
+		return regexp.Min == 0 || canBeEmpty(regexp.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range regexp.Sub {
+			if !canBeEmpty(sub) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpAlternate:
+		for _, sub := range regexp.Sub {
+			if canBeEmpty(sub) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func reverse(seq []*syntax.Regexp) []*syntax.Regexp {
+	reversed := make([]*syntax.Regexp, len(seq))
+	for i, regexp := range seq {
+		reversed[len(seq)-1-i] = regexp
+	}
+	return reversed
+}
@@ -0,0 +1,382 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"errors"
+	"regexp/syntax"
+	"unicode"
+)
+
+/*
+Zero-width assertions (anchors and word boundaries) are handled in four steps:
+
+ 1. satisfiable rejects expressions where anchors are provably misplaced, e.g. "a$b".
+ 2. narrowAssertions narrows the character classes next to assertions in sequences so that the assertions hold,
+    e.g. the "." in `a\b.` only generates non-word characters, or rejects the expression if that's impossible.
+ 3. Assertions that couldn't be settled by narrowing, e.g. ones in nested groups, are enforced by newAssertingGenerator
+    discarding generated strings that don't match the expression. If none of maxAssertionAttempts strings
+    matches, GenerateE returns ErrUnsatisfiable.
+ 4. Expressions narrowAssertions rejects because of a word boundary at their start or end, e.g. `\Bfoo`, are
+    generated with a word character before or after the match by newPaddedGenerator.
+*/
+
+// Number of strings generated for an expression with assertions before giving up on one matching it.
+const maxAssertionAttempts = 100
+
+// Rune ranges of the characters \b considers word characters, and of the rest.
+var (
+	wordRunes    = []rune{'0', '9', 'A', 'Z', '_', '_', 'a', 'z'}
+	nonWordRunes = []rune{0, '0' - 1, '9' + 1, 'A' - 1, 'Z' + 1, '_' - 1, '_' + 1, 'a' - 1, 'z' + 1, unicode.MaxRune}
+	newlineRunes = []rune{'\n', '\n'}
+)
+
+// narrowAssertions returns a copy of regexp with the character classes next to assertions narrowed so that the
+// assertions hold, or false if an assertion can never hold. regexp itself is not modified.
+func narrowAssertions(regexp *syntax.Regexp) (*syntax.Regexp, bool) {
+	return narrowAssertionsIn(regexp, true)
+}
+
+// top is whether regexp is the whole expression, so that the start and end of its sequence are the start and end
+// of the text.
+func narrowAssertionsIn(regexp *syntax.Regexp, top bool) (*syntax.Regexp, bool) {
+	if len(regexp.Sub) == 0 {
+		return regexp, true
+	}
+
+	narrowed := *regexp
+	narrowed.Sub = make([]*syntax.Regexp, len(regexp.Sub))
+	for i, sub := range regexp.Sub {
+		var ok bool
+		if narrowed.Sub[i], ok = narrowAssertionsIn(sub, false); !ok {
+			return nil, false
+		}
+	}
+
+	if regexp.Op == syntax.OpConcat {
+		subs := narrowed.Sub
+		for i, sub := range subs {
+			prev, next := edgeOf(subs, i-1, true, top), edgeOf(subs, i+1, false, top)
+			var ok bool
+			switch assertionOp(sub) {
+			case syntax.OpBeginLine:
+				ok = prev.require(newlineRunes)
+			case syntax.OpEndLine:
+				ok = next.require(newlineRunes)
+			case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+				ok = requireWordBoundary(prev, next, assertionOp(sub) == syntax.OpWordBoundary)
+			default:
+				ok = true
+			}
+			if !ok {
+				return nil, false
+			}
+		}
+	}
+	return &narrowed, true
+}
+
+// edge is what is known about the character on one side of an assertion.
+type edge struct {
+	// Whether the assertion is at the start or end of the text.
+	boundary bool
+	// The runes the character may be, as pairs of range bounds, or nil if unknown.
+	runes []rune
+	// Where to store the narrowed character class, if the character is one that can be narrowed.
+	narrow func(runes []rune)
+}
+
+// assertionOp returns the op of the assertion regexp is, seeing through groups that only hold one, or
+// syntax.OpNoMatch if regexp isn't an assertion.
+func assertionOp(regexp *syntax.Regexp) syntax.Op {
+	for regexp.Op == syntax.OpCapture && len(regexp.Sub) == 1 {
+		regexp = regexp.Sub[0]
+	}
+	switch regexp.Op {
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return regexp.Op
+	}
+	return syntax.OpNoMatch
+}
+
+// edgeOf returns what is known about the last (or first, if !last) character generated by subs[i]. Assertions
+// generate no characters, so they are skipped over.
+func edgeOf(subs []*syntax.Regexp, i int, last, top bool) edge {
+	step := 1
+	if last {
+		step = -1
+	}
+	for i >= 0 && i < len(subs) && assertionOp(subs[i]) != syntax.OpNoMatch {
+		i += step
+	}
+	if i < 0 || i >= len(subs) {
+		return edge{boundary: top}
+	}
+
+	return edgeOfNode(subs[i], last, func(narrowed *syntax.Regexp) { subs[i] = narrowed })
+}
+
+// edgeOfNode returns what is known about the last (or first, if !last) character generated by regexp.
+// replace replaces regexp in its (copied) parent.
+func edgeOfNode(regexp *syntax.Regexp, last bool, replace func(*syntax.Regexp)) edge {
+	var runes []rune
+	switch regexp.Op {
+	case syntax.OpCapture, syntax.OpConcat:
+		// Both are copies made by narrowAssertionsIn, so their subexpressions can be replaced.
+		if len(regexp.Sub) == 0 {
+			return edge{}
+		}
+		i := 0
+		if last {
+			i = len(regexp.Sub) - 1
+		}
+		return edgeOfNode(regexp.Sub[i], last, func(narrowed *syntax.Regexp) { regexp.Sub[i] = narrowed })
+	case syntax.OpLiteral:
+		if len(regexp.Rune) == 0 || regexp.Flags&syntax.FoldCase != 0 {
+			return edge{}
+		}
+		r := regexp.Rune[0]
+		if last {
+			r = regexp.Rune[len(regexp.Rune)-1]
+		}
+		return edge{runes: []rune{r, r}}
+	case syntax.OpCharClass:
+		runes = regexp.Rune
+	case syntax.OpAnyChar:
+		runes = []rune{0, unicode.MaxRune}
+	case syntax.OpAnyCharNotNL:
+		runes = []rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}
+	default:
+		return edge{}
+	}
+
+	return edge{runes: runes, narrow: func(narrowed []rune) {
+		replace(&syntax.Regexp{Op: syntax.OpCharClass, Flags: regexp.Flags, Rune: narrowed})
+	}}
+}
+
+// require narrows the character to allowed, returning false if it can't be any of them.
+// The start and end of the text satisfy any requirement.
+func (e edge) require(allowed []rune) bool {
+	if e.boundary || e.runes == nil {
+		return true
+	}
+	narrowed := intersectRuneRanges(e.runes, allowed)
+	if len(narrowed) == 0 {
+		return false
+	}
+	if e.narrow != nil {
+		e.narrow(narrowed)
+	}
+	return true
+}
+
+// isWord returns whether the character is a word character, and whether that is known.
+func (e edge) isWord() (word, known bool) {
+	if e.boundary {
+		return false, true
+	}
+	if e.runes == nil {
+		return false, false
+	}
+	if len(intersectRuneRanges(e.runes, nonWordRunes)) == 0 {
+		return true, true
+	}
+	if len(intersectRuneRanges(e.runes, wordRunes)) == 0 {
+		return false, true
+	}
+	return false, false
+}
+
+// requireWordBoundary narrows one of prev and next so that there is a word boundary between them, or not if
+// !boundary.
+func requireWordBoundary(prev, next edge, boundary bool) bool {
+	if word, known := prev.isWord(); known {
+		return next.requireWord(word != boundary)
+	}
+	if word, known := next.isWord(); known {
+		return prev.requireWord(word != boundary)
+	}
+	return true
+}
+
+// requireWord narrows the character to word characters, or non-word characters if !word. The start and end of
+// the text count as non-word characters.
+func (e edge) requireWord(word bool) bool {
+	if e.boundary {
+		return !word
+	}
+	if word {
+		return e.require(wordRunes)
+	}
+	return e.require(nonWordRunes)
+}
+
+// intersectRuneRanges returns the runes in both a and b, which are sorted pairs of range bounds as in
+// syntax.Regexp.Rune for character classes.
+func intersectRuneRanges(a, b []rune) []rune {
+	var result []rune
+	for i, j := 0, 0; i+1 < len(a) && j+1 < len(b); {
+		lo, hi := maxRune(a[i], b[j]), minRune(a[i+1], b[j+1])
+		if lo <= hi {
+			result = append(result, lo, hi)
+		}
+		if a[i+1] < b[j+1] {
+			i += 2
+		} else {
+			j += 2
+		}
+	}
+	return result
+}
+
+// hasInnerAssertions reports whether regexp contains assertions other than anchors at its very start and end,
+// which always hold.
+func hasInnerAssertions(regexp *syntax.Regexp) bool {
+	subs := []*syntax.Regexp{regexp}
+	if regexp.Op == syntax.OpConcat {
+		subs = regexp.Sub
+	}
+	for i, sub := range subs {
+		switch sub.Op {
+		case syntax.OpBeginText, syntax.OpBeginLine:
+			if i == 0 {
+				continue
+			}
+		case syntax.OpEndText, syntax.OpEndLine:
+			if i == len(subs)-1 {
+				continue
+			}
+		}
+		if containsAssertion(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAssertion(regexp *syntax.Regexp) bool {
+	switch regexp.Op {
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	}
+	for _, sub := range regexp.Sub {
+		if containsAssertion(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// newAssertingGenerator wraps generator to regenerate strings that don't match its expression, for expressions
+// with assertions narrowAssertions couldn't settle. Strings for which no matching one is found in
+// maxAssertionAttempts attempts fail with ErrUnsatisfiable: running out of attempts doesn't prove the expression
+// can't match, so it isn't rejected for it.
+func newAssertingGenerator(generator *internalGenerator) (*internalGenerator, error) {
+	full, err := generator.fullRegexp()
+	if err != nil {
+		return nil, err
+	}
+
+	generate := func(state *generatorState) (string, bool) {
+		var s string
+		for i := 0; i < maxAssertionAttempts; i++ {
+			// Only record the groups of the string returned.
+			state.resetCaptures()
+			if s = generator.generateString(state); full.MatchString(s) {
+				return s, true
+			}
+		}
+		return s, false
+	}
+
+	return &internalGenerator{
+		Name:   generator.Name,
+		regexp: generator.regexp,
+		args:   generator.args,
+		GenerateFunc: func(state *generatorState) {
+			s, ok := generate(state)
+			if !ok && !state.cancelled() {
+				state.fail(generatorError(ErrUnsatisfiable, "/%s/: no string satisfying its assertions found in %d attempts",
+					generator.regexp, maxAssertionAttempts))
+			}
+			state.out.WriteString(s)
+		},
+	}, nil
+}
+
+/*
+newPaddedGenerator creates a generator for an expression that can't match a whole string because of a word boundary
+at its start or end, e.g. `\Bfoo` or `-\b`, but can match within a string: it generates a word character before
+or after the match (or both), so that strings contain a match with the boundary holding. It returns err if padding
+doesn't help.
+*/
+func newPaddedGenerator(regexp *syntax.Regexp, args *GeneratorArgs, matchesExpression bool, err error) (
+	*internalGenerator, error) {
+	padStart, padEnd := hasEdgeWordBoundary(regexp, false), hasEdgeWordBoundary(regexp, true)
+	for _, pad := range [][2]bool{{true, false}, {false, true}, {true, true}} {
+		if (pad[0] && !padStart) || (pad[1] && !padEnd) {
+			continue
+		}
+
+		padded := &syntax.Regexp{Op: syntax.OpConcat, Flags: regexp.Flags}
+		if pad[0] {
+			padded.Sub = append(padded.Sub, &syntax.Regexp{Op: syntax.OpCharClass, Rune: wordRunes})
+		}
+		if regexp.Op == syntax.OpConcat {
+			// Spliced in rather than nested, so that narrowAssertions sees the padding next to the edge assertions.
+			padded.Sub = append(padded.Sub, regexp.Sub...)
+		} else {
+			padded.Sub = append(padded.Sub, regexp)
+		}
+		if pad[1] {
+			padded.Sub = append(padded.Sub, &syntax.Regexp{Op: syntax.OpCharClass, Rune: wordRunes})
+		}
+
+		gen, paddedErr := newAssertedGenerator(padded, args, matchesExpression)
+		if !errors.Is(paddedErr, ErrUnsatisfiable) {
+			return gen, paddedErr
+		}
+	}
+	return nil, err
+}
+
+// hasEdgeWordBoundary returns whether regexp may start (or end, if last) with \b or \B.
+func hasEdgeWordBoundary(regexp *syntax.Regexp, last bool) bool {
+	switch regexp.Op {
+	case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	case syntax.OpConcat:
+		if len(regexp.Sub) == 0 {
+			return false
+		}
+		if last {
+			return hasEdgeWordBoundary(regexp.Sub[len(regexp.Sub)-1], last)
+		}
+		return hasEdgeWordBoundary(regexp.Sub[0], last)
+	case syntax.OpCapture, syntax.OpAlternate:
+		for _, sub := range regexp.Sub {
+			if hasEdgeWordBoundary(sub, last) {
+				return true
+			}
+		}
+	}
+	return false
+}
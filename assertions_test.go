@@ -0,0 +1,266 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"errors"
+	"math/rand"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestAssertions(t *testing.T) {
+	t.Parallel()
+
+	generatesMatches := func(t *testing.T, flags syntax.Flags, patterns ...string) {
+		for _, pattern := range patterns {
+			generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: flags, MaxUnboundedRepeatCount: 8})
+			if err != nil {
+				t.Fatalf("/%s/: %v", pattern, err)
+			}
+			compiled, err := generator.CompiledRegexp()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < SampleSize; i++ {
+				s := generator.Generate()
+				if loc := compiled.FindStringIndex(s); loc == nil || loc[0] != 0 || loc[1] != len(s) {
+					t.Fatalf("%q generated from /%s/ does not match it", s, pattern)
+				}
+			}
+		}
+	}
+
+	t.Run("Text anchors", func(t *testing.T) {
+		t.Parallel()
+
+		generatesMatches(t, syntax.Perl, `^abc$`, `\Aabc\z`, `a?\Ab`, `a\z|b`, `(\Aa|b)c`, `a(b\z|c)`, `x*\A\w+`)
+	})
+
+	t.Run("Absolute anchors under Multiline", func(t *testing.T) {
+		t.Parallel()
+
+		generatesMatches(t, syntax.Perl, `(?m)\Aa$\n^b\z`, `(?m)(\Aa|b)$`, `a\Z`, `(?m)^a$\Z`, `(a|b\Z)c?`)
+	})
+
+	t.Run("End of text before trailing newline", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`[ab]\Z`, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		newlines := 0
+		for i := 0; i < SampleSize; i++ {
+			s := generator.Generate()
+			if s != "a" && s != "b" && s != "a\n" && s != "b\n" {
+				t.Fatalf("wrong output: %q", s)
+			}
+			if strings.HasSuffix(s, "\n") {
+				newlines++
+			}
+		}
+		if newlines == 0 || newlines == SampleSize {
+			t.Fatal("should generate a trailing newline some of the time:", newlines)
+		}
+		if _, err := NewGenerator(`a\Z`, nil); err == nil {
+			t.Fatal("err should not be nil without PerlX")
+		}
+	})
+
+	t.Run("Line anchors", func(t *testing.T) {
+		t.Parallel()
+
+		generatesMatches(t, syntax.Perl, `(?m)^a$\n^b$`, `(?m)[a-c\n]^x`, `(?ms)x$.`, `(?m)(a|\n)^b`,
+			`(?m)a(^b|\n)`, `(?m)(\w$|\n)+`)
+		generatesMatches(t, 0, `a$\n?`, `a\n?^b`)
+	})
+
+	t.Run("Word boundaries", func(t *testing.T) {
+		t.Parallel()
+
+		generatesMatches(t, syntax.Perl, `\bfoo\b`, `a\b.`, `-\b.`, `.\ba`, `.\b-`, `\b.`, `.\b`, `[a-]\b[b-]`,
+			`(a|-)\b(b|-)`, `(\w|\W)\b`, `\b(\w+\W+)+`)
+	})
+
+	t.Run("Non-word boundaries", func(t *testing.T) {
+		t.Parallel()
+
+		generatesMatches(t, syntax.Perl, `a\B.`, `-\B.`, `\B.`, `.\B`, `[a-]\B[b-]`, `(a|-)\B(b|-)`, `\w+\B\w+`)
+	})
+
+	t.Run("Narrows neighbors", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			pattern  string
+			expected string
+		}{
+			{`a\b.`, `a\b[^\n0-9A-Z_a-z]`},
+			{`(a)\b(.)`, `(a)\b([^\n0-9A-Z_a-z])`},
+			{`.\b-`, `[0-9A-Z_a-z]\b-`},
+			{`\b.`, `\b[0-9A-Z_a-z]`},
+			{`(?ms)x$.`, `(?m:x$[\n])`},
+			{`(?m)[ab\n]^x`, `(?m:[\n]^x)`},
+			{`[a-]\b[b-]`, `[\-a]\b[\-b]`},
+		} {
+			parsed, err := syntax.Parse(tc.pattern, syntax.Perl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			original := parsed.String()
+
+			narrowed, ok := narrowAssertions(parsed)
+			if !ok {
+				t.Fatalf("/%s/ should be satisfiable", tc.pattern)
+			}
+			if narrowed.String() != tc.expected {
+				t.Fatalf("/%s/ should be narrowed to /%s/, was /%s/", tc.pattern, tc.expected, narrowed)
+			}
+			if parsed.String() != original {
+				t.Fatal("should not modify the input")
+			}
+		}
+	})
+
+	t.Run("Conflicting assertions are unsatisfiable", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`a\bb`, `-\b-`, `a\B-`, `(?m)[a-z]$[a-z]`,
+			`(?m)[a-z]^x`, `(?m)x$.`, `\ba\B\b`, `(a\bb|c\bd)`, `(?m)(x$y)+`} {
+			_, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl})
+			if !errors.Is(err, ErrUnsatisfiable) {
+				t.Fatalf("/%s/ should be ErrUnsatisfiable, was %v", pattern, err)
+			}
+		}
+	})
+
+	t.Run("Satisfiable assertions are not rejected", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`a*\bb`, `(a\b)*b`, `.\b.\b.`} {
+			generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl})
+			if err != nil {
+				t.Fatalf("/%s/: %v", pattern, err)
+			}
+			compiled := regexp.MustCompile(`^(?:` + pattern + `)$`)
+			for i := 0; i < SampleSize; i++ {
+				s, err := generator.GenerateE()
+				if err != nil {
+					if !errors.Is(err, ErrUnsatisfiable) {
+						t.Fatalf("/%s/: %v", pattern, err)
+					}
+					continue
+				}
+				if !compiled.MatchString(s) {
+					t.Fatalf("%q generated from /%s/ does not match it", s, pattern)
+				}
+			}
+		}
+	})
+
+	t.Run("Pads word boundaries at the edges", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			pattern string
+			padded  string
+		}{
+			{`\Bfoo`, `^\wfoo$`},
+			{`foo\B`, `^foo\w$`},
+			{`\b-`, `^\w-$`},
+			{`-\b`, `^-\w$`},
+			{`\B(?:a)`, `^\wa$`},
+			{`(\B)a`, `^\wa$`},
+			{`\B-\B`, `^-$`},
+			{`\b-\b`, `^\w-\w$`},
+		} {
+			generator, err := NewGenerator(tc.pattern, &GeneratorArgs{Flags: syntax.Perl})
+			if err != nil {
+				t.Fatalf("/%s/: %v", tc.pattern, err)
+			}
+			for i := 0; i < SampleSize; i++ {
+				s := generator.Generate()
+				if matched, _ := regexp.MatchString(tc.pattern, s); !matched {
+					t.Fatalf("%q generated from /%s/ does not contain a match", s, tc.pattern)
+				}
+				if matched, _ := regexp.MatchString(tc.padded, s); !matched {
+					t.Fatalf("%q generated from /%s/ should match /%s/", s, tc.pattern, tc.padded)
+				}
+			}
+		}
+
+		if _, err := NewGenerator(`\Aa\B`, &GeneratorArgs{Flags: syntax.Perl}); err != nil {
+			t.Fatal("err should be nil:", err)
+		}
+		if _, err := NewGenerator(`\A\Ba\z`, &GeneratorArgs{Flags: syntax.Perl}); !errors.Is(err, ErrUnsatisfiable) {
+			t.Fatal("should not pad around text anchors, was", err)
+		}
+	})
+
+	t.Run("Not checked with custom capture groups", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(a)\b(bc|-)`, &GeneratorArgs{
+			Flags: syntax.Perl,
+			CaptureGroupHandler: func(_ int, _ string, _ *syntax.Regexp, generator Generator, _ *GeneratorArgs) string {
+				return generator.Generate()
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := map[string]bool{}
+		for i := 0; i < SampleSize; i++ {
+			seen[generator.Generate()] = true
+		}
+		if !seen["abc"] {
+			t.Fatalf("should generate strings not satisfying the assertion, generated %v", seen)
+		}
+	})
+}
+
+func TestAssertingGeneratorFails(t *testing.T) {
+	t.Parallel()
+
+	// Most strings generated don't match, so some run out of attempts.
+	pattern := `(?:([a-z ]{1,3})\b){5}`
+	generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, RngSource: rand.NewSource(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := regexp.MustCompile(`^(?:` + pattern + `)$`)
+
+	failed := 0
+	for i := 0; i < SampleSize; i++ {
+		s, err := generator.GenerateE()
+		if err != nil {
+			if !errors.Is(err, ErrUnsatisfiable) {
+				t.Fatalf("err should be ErrUnsatisfiable, was %v", err)
+			}
+			failed++
+		} else if !full.MatchString(s) {
+			t.Fatalf("%q doesn't match, but GenerateE didn't fail", s)
+		}
+	}
+	if failed == 0 {
+		t.Fatal("some strings should fail")
+	}
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+/*
+Backreferences \1 to \9 repeat the last value generated for capture groups 1 to 9, e.g. `(\w+)=\1` generates
+"ab=ab", and named backreferences (?P=name) the last value generated for the group with that name. A group not
+generated yet, e.g. in `(a)?\1` or `\1(a)`, is repeated as an empty string.
+
+syntax.Parse rejects backreferences, so they are rewritten to runes from the private use area before parsing, and
+generated from the values recorded for their groups. Each rune is wrapped in a repetition of 1, so that the parser
+doesn't merge it into a character class with other characters it is alternated with. Named backreferences are
+rewritten to runes standing for their names first, and then replaced by the runes standing for the groups with
+those names once the expression is parsed. Go regexps can't match backreferences either, so CompiledRegexp
+returns an error for expressions with them.
+*/
+
+// The runes standing for backreferences to groups 1 to 255 are backreferenceBase+1 to backreferenceBase+255.
+const backreferenceBase = '\U0010FF00'
+
+// The runes standing for the first 256 names referred to by named backreferences, before they are resolved.
+const namedBackreferenceBase = '\U0010FE00'
+
+// A named backreference: (?P=name).
+var namedBackreference = regexp.MustCompile(`^\(\?P=(\w+)\)`)
+
+// backreferenceIndex returns the 0-based index of the group r refers to, if r stands for a backreference.
+func backreferenceIndex(r rune) (int, bool) {
+	if r > backreferenceBase && r <= backreferenceBase+0xff {
+		return int(r-backreferenceBase) - 1, true
+	}
+	return 0, false
+}
+
+// rewriteBackreferences replaces the backreferences in pattern with the runes standing for them. It returns the
+// number of the highest group referred to by number, or 0 if there is none, and the names referred to.
+// Under PerlX, it also rewrites `\Z`, which syntax.Parse rejects too, to the equivalent `(?:\n?\z)`: the end of
+// the text, or before a newline ending it, and control escapes like `\cK` to the hex escape of their rune.
+// Like syntax.Parse, it takes `\1` to `\7` followed by an octal digit for an octal escape, e.g. `\101` for "A".
+func rewriteBackreferences(pattern string, flags syntax.Flags) (string, int, []string, error) {
+	// Without PerlX, (?: isn't allowed, but repetitions may be nested.
+	reference := `\x{%x}{1}`
+	if flags&syntax.PerlX != 0 {
+		reference = `(?:\x{%x}{1})`
+	}
+
+	var result strings.Builder
+	highest := 0
+	var names []string
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], `\Q`):
+			// Copy quoted text as is.
+			end := quoteEnd(pattern, i)
+			result.WriteString(pattern[i:end])
+			i = end
+		case octalEscape(pattern[i:]):
+			result.WriteString(pattern[i : i+2])
+			i += 2
+		case pattern[i] == '\\' && i+1 < len(pattern) && pattern[i+1] >= '1' && pattern[i+1] <= '9':
+			group := int(pattern[i+1] - '0')
+			if group > highest {
+				highest = group
+			}
+			fmt.Fprintf(&result, reference, backreferenceBase+rune(group))
+			i += 2
+		case strings.HasPrefix(pattern[i:], `\Z`) && flags&syntax.PerlX != 0:
+			result.WriteString(`(?:\n?\z)`)
+			i += 2
+		case strings.HasPrefix(pattern[i:], `\c`) && flags&syntax.PerlX != 0:
+			if i+2 >= len(pattern) || !isASCIILetter(pattern[i+2]) {
+				return "", 0, nil, generatorError(nil, "invalid control escape at offset %d in /%s/", i, pattern)
+			}
+			fmt.Fprintf(&result, `\x{%x}`, pattern[i+2]&0x1f)
+			i += 3
+		case pattern[i] == '\\':
+			end := i + 2
+			if end > len(pattern) {
+				end = len(pattern)
+			}
+			result.WriteString(pattern[i:end])
+			i = end
+		case pattern[i] == '[':
+			end := classEnd(pattern, i)
+			result.WriteString(pattern[i:end])
+			i = end
+		case strings.HasPrefix(pattern[i:], "(?P="):
+			match := namedBackreference.FindStringSubmatch(pattern[i:])
+			if match == nil {
+				return "", 0, nil, generatorError(nil, "invalid named backreference at offset %d in /%s/", i, pattern)
+			}
+			n := indexOf(names, match[1])
+			if n < 0 {
+				if len(names) > 0xff {
+					return "", 0, nil, generatorError(nil, "too many named backreferences in /%s/", pattern)
+				}
+				n = len(names)
+				names = append(names, match[1])
+			}
+			fmt.Fprintf(&result, reference, namedBackreferenceBase+rune(n))
+			i += len(match[0])
+		default:
+			result.WriteByte(pattern[i])
+			i++
+		}
+	}
+	return result.String(), highest, names, nil
+}
+
+// resolveNamedBackreferences replaces the runes standing for names in the literals of regexp, as rewritten by
+// rewriteBackreferences, with the runes standing for the groups with those names.
+func resolveNamedBackreferences(regexp *syntax.Regexp, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	groups := map[string]int{}
+	walkRegexp(regexp, func(r *syntax.Regexp) {
+		if r.Op == syntax.OpCapture && r.Name != "" {
+			groups[r.Name] = r.Cap
+		}
+	})
+
+	var err error
+	walkRegexp(regexp, func(r *syntax.Regexp) {
+		if r.Op != syntax.OpLiteral {
+			return
+		}
+		for i, c := range r.Rune {
+			if c < namedBackreferenceBase || c > namedBackreferenceBase+0xff {
+				continue
+			}
+			name := names[c-namedBackreferenceBase]
+			group, ok := groups[name]
+			switch {
+			case !ok:
+				err = generatorError(nil, "named backreference to unknown group %q", name)
+			case group > 0xff:
+				err = generatorError(nil, "named backreference to group %q, beyond group 255", name)
+			default:
+				r.Rune[i] = backreferenceBase + rune(group)
+			}
+		}
+	})
+	return err
+}
+
+// walkRegexp calls f for regexp and each of its sub-expressions.
+func walkRegexp(regexp *syntax.Regexp, f func(*syntax.Regexp)) {
+	f(regexp)
+	for _, sub := range regexp.Sub {
+		walkRegexp(sub, f)
+	}
+}
+
+// octalEscape reports whether s begins with an octal escape other than `\0`, which could be taken for a
+// backreference, e.g. `\101`.
+func octalEscape(s string) bool {
+	return len(s) > 2 && s[0] == '\\' && s[1] >= '1' && s[1] <= '7' && s[2] >= '0' && s[2] <= '7'
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// createBackreferenceGenerator returns a generator for a literal containing backreferences, or nil if it
+// contains none.
+func createBackreferenceGenerator(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
+	var segments []*internalGenerator
+	text := 0
+	addText := func(end int) error {
+		if text < end {
+			literal := &syntax.Regexp{Op: syntax.OpLiteral, Rune: regexp.Rune[text:end], Flags: regexp.Flags}
+			generator, err := opLiteral(literal, args)
+			if err != nil {
+				return err
+			}
+			segments = append(segments, generator)
+		}
+		return nil
+	}
+
+	for i, r := range regexp.Rune {
+		index, ok := backreferenceIndex(r)
+		if !ok {
+			continue
+		}
+		if err := addText(i); err != nil {
+			return nil, err
+		}
+		segments = append(segments, &internalGenerator{Name: fmt.Sprintf(`\%d`, index+1),
+			GenerateFunc: func(state *generatorState) {
+				state.out.WriteString(state.groups[index])
+			}})
+		text = i + 1
+	}
+	if segments == nil {
+		return nil, nil
+	}
+	if err := addText(len(regexp.Rune)); err != nil {
+		return nil, err
+	}
+
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+		for _, segment := range segments {
+			segment.GenerateFunc(state)
+		}
+	}}, nil
+}
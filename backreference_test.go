@@ -0,0 +1,140 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestBackreferences(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Repeats the group", func(t *testing.T) {
+		t.Parallel()
+
+		for _, flags := range []syntax.Flags{0, syntax.Perl} {
+			generator, err := NewGenerator(`([a-z]{3})-\1`, &GeneratorArgs{Flags: flags})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < SampleSize; i++ {
+				s := generator.Generate()
+				halves := strings.Split(s, "-")
+				if len(halves) != 2 || len(halves[0]) != 3 || halves[0] != halves[1] {
+					t.Fatalf("%q should have equal halves", s)
+				}
+			}
+		}
+	})
+
+	t.Run("Perl", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{Flags: syntax.Perl}, `(\w{3})=\1`, `^(\w{3})=(\w{3})$`)
+		generator, _ := NewGenerator(`(\w{3})(\d)=\2\1`, &GeneratorArgs{Flags: syntax.Perl})
+		for i := 0; i < SampleSize; i++ {
+			s := generator.Generate()
+			if s[5:] != s[3:4]+s[:3] {
+				t.Fatalf("%q should repeat its groups in reverse", s)
+			}
+		}
+	})
+
+	t.Run("Repeated and alternated", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`(a|b)x(\1|c)\1*`, &GeneratorArgs{Flags: syntax.Perl, MaxUnboundedRepeatCount: 3})
+		for i := 0; i < SampleSize; i++ {
+			s := generator.Generate()
+			for _, r := range s[2:] {
+				if r != 'c' && r != rune(s[0]) {
+					t.Fatalf("%q should only repeat %q or c", s, s[0])
+				}
+			}
+		}
+	})
+
+	t.Run("Last value of a repeated group", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`(?:([a-z]),)+=\1`, &GeneratorArgs{Flags: syntax.Perl, MaxUnboundedRepeatCount: 10})
+		for i := 0; i < SampleSize; i++ {
+			s := generator.Generate()
+			if s[len(s)-4] != s[len(s)-1] {
+				t.Fatalf("%q should end with its last group", s)
+			}
+		}
+	})
+
+	t.Run("Group not generated", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{Flags: syntax.Perl}, `\1(a)`, `^a$`)
+	})
+
+	t.Run("Escaped and quoted", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{Flags: syntax.Perl}, `(a)\\1\Q\1\E`, `^a\\1\\1$`)
+	})
+
+	t.Run("Named", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(?P<tok>[a-z]{4}).*(?P=tok)(?P<n>\d)(?P=n)`, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			s := []rune(generator.Generate())
+			n := len(s)
+			if string(s[:4]) != string(s[n-6:n-2]) || s[n-2] != s[n-1] {
+				t.Fatalf("%q should repeat its named groups", string(s))
+			}
+		}
+
+		for _, pattern := range []string{`(?P<a>x)(?P=b)`, `(?P<a>x)(?P=a`} {
+			if _, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl}); err == nil {
+				t.Fatalf("err should not be nil for /%s/", pattern)
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewGenerator(`(a)\2`, nil); err == nil {
+			t.Fatal("err should not be nil")
+		}
+		generator, _ := NewGenerator(`(a)\1`, nil)
+		if _, err := generator.CompiledRegexp(); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+
+	t.Run("Alphabet", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`([ab])\1`, nil)
+		if alphabet := string(generator.Alphabet()); alphabet != "ab" {
+			t.Fatal("should be equal:", alphabet, "ab")
+		}
+	})
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"sort"
+)
+
+// CaptureGroup describes a capture group of a generator's expression.
+type CaptureGroup struct {
+	// Index of the group, 0-based, as passed to CaptureGroupHandler.
+	Index int
+	// Name of the group, e.g. "year" for `(?P<year>\d{4})`, or empty if it has none.
+	Name string
+	// The group's subexpression, as formatted by syntax.Regexp.String, e.g. `[0-9]{4}` for `(?P<year>\d{4})`.
+	Expression string
+}
+
+// CaptureGroups returns the capture groups of the generator's expression, in order of their index.
+func (gen *internalGenerator) CaptureGroups() []CaptureGroup {
+	if gen.regexp == nil {
+		return nil
+	}
+	var groups []CaptureGroup
+	walkRegexp(gen.regexp, func(r *syntax.Regexp) {
+		if r.Op == syntax.OpCapture {
+			groups = append(groups, CaptureGroup{Index: r.Cap - 1, Name: r.Name, Expression: r.Sub[0].String()})
+		}
+	})
+	return groups
+}
+
+/*
+GenerateWithRepeatedGroups generates a string, and returns it with the values generated for each capture group,
+keyed by the group's index (0-based, as passed to CaptureGroupHandler). Values are in the order they were
+generated, so for `(\w+)(,\w+)*` the values of group 1 are the elements of the list after the first.
+
+Groups that weren't generated, e.g. in a repetition generated 0 times, are not in the map.
+*/
+func (gen *internalGenerator) GenerateWithRepeatedGroups() (string, map[int][]string) {
+	state := gen.newState()
+	state.captures = map[int][]string{}
+	return gen.generateString(state), state.captures
+}
+
+/*
+GenerateWithCaptures generates a string, and returns it with the values generated for its capture groups, like a
+regexp match would: named has the values of named groups, and indexed those of all groups by 0-based index (as
+passed to CaptureGroupHandler). Groups generated more than once, e.g. in a repetition, have the last value
+generated. Groups that weren't generated are "" in indexed, and not in named.
+*/
+func (gen *internalGenerator) GenerateWithCaptures() (string, map[string]string, []string) {
+	s, groups := gen.GenerateWithRepeatedGroups()
+	named := map[string]string{}
+	if gen.regexp == nil {
+		return s, named, nil
+	}
+	names := gen.regexp.CapNames()
+	indexed := make([]string, len(names)-1)
+	for index, values := range groups {
+		indexed[index] = values[len(values)-1]
+		if name := names[index+1]; name != "" {
+			named[name] = indexed[index]
+		}
+	}
+	return s, named, indexed
+}
+
+// CaptureSpan is where the value generated for a capture group is in a generated string.
+type CaptureSpan struct {
+	// Index of the group, 0-based, as passed to CaptureGroupHandler.
+	Index int
+	// Name of the group, or empty if it has none.
+	Name string
+	// Byte offsets of the value in the string, so that it is s[Start:End].
+	Start, End int
+}
+
+// capturedSpan is a CaptureSpan, with the value written there.
+type capturedSpan struct {
+	CaptureSpan
+	value string
+}
+
+/*
+GenerateWithSpans generates a string, and returns it with where the value of each capture group generated is in it,
+ordered by offset, with enclosing groups before the groups nested in them. Groups generated more than once, e.g. in
+a repetition, have a span for every value.
+
+Only values found where they were written are reported, so groups changed after they were generated, e.g. by
+WhitespacePolicy, or nested in a group whose CaptureGroupHandler returns something else, are left out.
+*/
+func (gen *internalGenerator) GenerateWithSpans() (string, []CaptureSpan) {
+	state := gen.newState()
+	state.spans = []capturedSpan{}
+	s := gen.generateString(state)
+
+	spans := make([]CaptureSpan, 0, len(state.spans))
+	for _, span := range state.spans {
+		if span.End <= len(s) && s[span.Start:span.End] == span.value {
+			spans = append(spans, span.CaptureSpan)
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		if spans[i].End != spans[j].End {
+			return spans[i].End > spans[j].End
+		}
+		return spans[i].Index < spans[j].Index
+	})
+	return s, spans
+}
+
+// outputLen returns the number of bytes written to out, or -1 if it can't tell.
+func outputLen(out output) int {
+	switch out := out.(type) {
+	case interface{ Len() int }:
+		return out.Len()
+	case *sizeLimitedOutput:
+		return outputLen(out.out)
+	}
+	return -1
+}
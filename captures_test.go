@@ -0,0 +1,217 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math/rand"
+	"reflect"
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestGenerateWithRepeatedGroups(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Comma-separated list", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(\w{1,5})(,(\w{1,5}))*`, &GeneratorArgs{
+			Flags:                   syntax.Perl,
+			MaxUnboundedRepeatCount: 8,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < SampleSize; i++ {
+			s, groups := generator.GenerateWithRepeatedGroups()
+			elements := strings.Split(s, ",")
+
+			if len(groups[0]) != 1 || groups[0][0] != elements[0] {
+				t.Fatalf("group 0 of %q should be the first element, was %q", s, groups[0])
+			}
+			if len(groups[1]) != len(elements)-1 || len(groups[2]) != len(elements)-1 {
+				t.Fatalf("groups 1 and 2 of %q should have a value per remaining element, were %q and %q",
+					s, groups[1], groups[2])
+			}
+			for j, element := range elements[1:] {
+				if groups[1][j] != ","+element || groups[2][j] != element {
+					t.Fatalf("values of %q should be in order, were %q and %q", s, groups[1], groups[2])
+				}
+			}
+		}
+	})
+
+	t.Run("Pooled and handled groups", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`(?P<a>x)(y)`, &GeneratorArgs{
+			Flags:           syntax.Perl,
+			GroupValuePools: map[string][]string{"a": {"pooled"}},
+			CaptureGroupHandler: func(_ int, _ string, _ *syntax.Regexp, _ Generator, _ *GeneratorArgs) string {
+				return "handled"
+			},
+		})
+		s, groups := generator.GenerateWithRepeatedGroups()
+		if s != "pooledhandled" || groups[0][0] != "pooled" || groups[1][0] != "handled" {
+			t.Fatalf("should record the emitted values, was %q and %q", s, groups)
+		}
+	})
+}
+
+func TestGenerateWithCaptures(t *testing.T) {
+	t.Parallel()
+
+	generator, err := NewGenerator(`(?P<user>[a-z]{3,8})@(?P<domain>[a-z]{3,8})\.(com|org)(-[0-9])*(x)?`, &GeneratorArgs{
+		Flags: syntax.Perl,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < SampleSize; i++ {
+		s, named, indexed := generator.GenerateWithCaptures()
+		if len(indexed) != 5 {
+			t.Fatalf("should have a value per group, was %q", indexed)
+		}
+		if expected := named["user"] + "@" + named["domain"] + "." + indexed[2]; !strings.HasPrefix(s, expected) {
+			t.Fatalf("%q should start with %q", s, expected)
+		}
+		if named["user"] != indexed[0] || named["domain"] != indexed[1] || len(named) != 2 {
+			t.Fatalf("named values %q should be those of the named groups in %q", named, indexed)
+		}
+		if repeats := strings.Count(s, "-"); repeats > 0 && indexed[3] != s[strings.LastIndex(s, "-"):][:2] {
+			t.Fatalf("group 3 of %q should be the last repetition, was %q", s, indexed[3])
+		} else if repeats == 0 && indexed[3] != "" {
+			t.Fatalf("group 3 of %q should be empty, was %q", s, indexed[3])
+		}
+		if strings.HasSuffix(s, "x") != (indexed[4] == "x") {
+			t.Fatalf("group 4 of %q should be x only if generated, was %q", s, indexed[4])
+		}
+	}
+}
+
+func TestGenerateWithSpans(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Spans cover the captured values", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`x(?P<key>[a-z]{1,5})=((é|[0-9])+)(;(\w{1,3}))*`, &GeneratorArgs{
+			Flags:                   syntax.Perl,
+			MaxUnboundedRepeatCount: 5,
+			RngSource:               rand.NewSource(1),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := NewGenerator(`x(?P<key>[a-z]{1,5})=((é|[0-9])+)(;(\w{1,3}))*`, &GeneratorArgs{
+			Flags:                   syntax.Perl,
+			MaxUnboundedRepeatCount: 5,
+			RngSource:               rand.NewSource(1),
+		})
+
+		for i := 0; i < SampleSize; i++ {
+			s, spans := generator.GenerateWithSpans()
+			_, groups := expected.GenerateWithRepeatedGroups()
+
+			count := 0
+			for _, values := range groups {
+				count += len(values)
+			}
+			if len(spans) != count {
+				t.Fatalf("%q should have a span per value in %q, had %v", s, groups, spans)
+			}
+			seen := map[int]int{}
+			for j, span := range spans {
+				if j > 0 && span.Start < spans[j-1].Start {
+					t.Fatalf("spans of %q should be ordered, were %v", s, spans)
+				}
+				value := s[span.Start:span.End]
+				if k := seen[span.Index]; value != groups[span.Index][k] {
+					t.Fatalf("span %v of %q should cover %q, covered %q", span, s, groups[span.Index][k], value)
+				}
+				seen[span.Index]++
+				if (span.Name == "key") != (span.Index == 0) {
+					t.Fatalf("span %v should only be named for group 0", span)
+				}
+			}
+			if spans[0].Start != 1 || s[spans[1].Start-1] != '=' {
+				t.Fatalf("spans of %q should start after x and =, were %v", s, spans)
+			}
+		}
+	})
+
+	t.Run("Handled groups", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`a(b(c))d(e)`, &GeneratorArgs{
+			CaptureGroupHandler: func(index int, _ string, _ *syntax.Regexp, generator Generator, _ *GeneratorArgs) string {
+				if index == 2 {
+					return "EE"
+				}
+				return generator.Generate()
+			},
+		})
+		s, spans := generator.GenerateWithSpans()
+		expected := []CaptureSpan{{Index: 0, Start: 1, End: 3}, {Index: 1, Start: 2, End: 3}, {Index: 2, Start: 4, End: 6}}
+		if s != "abcdEE" || !reflect.DeepEqual(spans, expected) {
+			t.Fatalf("should be abcdEE with spans %v, was %q with %v", expected, s, spans)
+		}
+	})
+}
+
+func TestCaptureGroups(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Lists groups in order", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(?P<year>\d{4})-(\d{2}(?P<day>-\d{2})?)`, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []CaptureGroup{
+			{Index: 0, Name: "year", Expression: `[0-9]{4}`},
+			{Index: 1, Name: "", Expression: `[0-9]{2}(?P<day>-[0-9]{2})?`},
+			{Index: 2, Name: "day", Expression: `-[0-9]{2}`},
+		}
+		groups := generator.CaptureGroups()
+		if len(groups) != len(expected) {
+			t.Fatal("should be equal:", groups, expected)
+		}
+		for i := range groups {
+			if groups[i] != expected[i] {
+				t.Fatal("should be equal:", groups[i], expected[i])
+			}
+		}
+	})
+
+	t.Run("Empty without groups", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`abc`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if groups := generator.CaptureGroups(); len(groups) != 0 {
+			t.Fatal("should be empty:", groups)
+		}
+	})
+}
@@ -19,6 +19,11 @@ package regen
 
 import (
 	"fmt"
+	"math/rand"
+	"regexp/syntax"
+	"sort"
+	"sync"
+	"unicode"
 )
 
 // CharClass represents a regular expression character class as a list of ranges.
@@ -43,6 +48,90 @@ func newCharClass(start rune, end rune) *tCharClass {
 	}
 }
 
+// generatedClass returns the class of runes generated for a character class or "." node, restricted as args say,
+// or nil if none is left.
+func generatedClass(regexp *syntax.Regexp, args *GeneratorArgs) *tCharClass {
+	var class *tCharClass
+	switch regexp.Op {
+	case syntax.OpCharClass:
+		class = parseCharClass(regexp.Rune)
+	case syntax.OpAnyChar:
+		class = anyCharClass(args, true)
+	case syntax.OpAnyCharNotNL:
+		class = anyCharClass(args, false)
+	}
+	if class != nil && args.ASCIIOnly {
+		class = class.intersect(1, unicode.MaxASCII)
+	}
+	if class != nil && args.PrintableOnly {
+		class = class.intersectClass(printableClass())
+	}
+	if class != nil && args.allowed != nil {
+		class = class.intersectClass(args.allowed)
+	}
+	return class
+}
+
+var (
+	printableOnce sync.Once
+	printable     *tCharClass
+)
+
+// printableClass returns the class of the runes unicode.IsPrint reports as printable.
+func printableClass() *tCharClass {
+	printableOnce.Do(func() {
+		var runes []rune
+		for r := rune(1); r <= unicode.MaxRune; r++ {
+			if unicode.IsPrint(r) {
+				runes = append(runes, r)
+			}
+		}
+		printable = runeSetClass(runes)
+	})
+	return printable
+}
+
+// anyCharClass returns the class of runes generated for ".": AnyCharRunes if set, or every valid rune except NUL,
+// and without newline unless matchNL is set. Surrogate halves are excluded since they cannot be encoded in UTF-8.
+// It returns nil if no rune is left.
+func anyCharClass(args *GeneratorArgs, matchNL bool) *tCharClass {
+	if len(args.AnyCharRunes) > 0 {
+		runes := make([]rune, 0, len(args.AnyCharRunes))
+		for _, r := range args.AnyCharRunes {
+			if matchNL || r != '\n' {
+				runes = append(runes, r)
+			}
+		}
+		return runeSetClass(runes)
+	}
+	if matchNL {
+		return parseCharClass([]rune{1, 0xD7FF, 0xE000, unicode.MaxRune})
+	}
+	return parseCharClass([]rune{1, '\n' - 1, '\n' + 1, 0xD7FF, 0xE000, unicode.MaxRune})
+}
+
+// runeSetClass returns the class of the given runes, in any order and possibly repeated, or nil if there are none.
+func runeSetClass(runes []rune) *tCharClass {
+	sorted := append([]rune(nil), runes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var class tCharClass
+	for i := 0; i < len(sorted); {
+		// Merge runs of consecutive runes into a range.
+		j := i + 1
+		for j < len(sorted) && sorted[j] <= sorted[j-1]+1 {
+			j++
+		}
+		class.Ranges = append(class.Ranges, newCharClassRange(sorted[i], sorted[j-1]))
+		class.TotalSize += int32(sorted[j-1]-sorted[i]) + 1
+		i = j
+	}
+	if class.TotalSize == 0 {
+		return nil
+	}
+	return &class
+}
+
 /*
 ParseCharClass parses a character class as represented by syntax.Parse into a slice of CharClassRange structs.
 
@@ -80,6 +169,15 @@ func parseCharClass(runes []rune) *tCharClass {
 	return &tCharClass{ranges, totalSize}
 }
 
+// runeRanges returns the ranges of class as pairs of runes, like syntax.Regexp.Rune.
+func (class *tCharClass) runeRanges() []rune {
+	runes := make([]rune, 0, 2*len(class.Ranges))
+	for _, r := range class.Ranges {
+		runes = append(runes, r.Start, r.Start+rune(r.Size)-1)
+	}
+	return runes
+}
+
 // GetRuneAt gets a rune from CharClass as a contiguous array of runes.
 func (class *tCharClass) GetRuneAt(i int32) rune {
 	for _, r := range class.Ranges {
@@ -118,3 +216,75 @@ func (r tCharClassRange) String() string {
 	}
 	return fmt.Sprintf("%s-%s:%d", runesToString(r.Start), runesToString(r.Start+rune(r.Size-1)), r.Size)
 }
+
+// intersect returns the runes of class between start and end inclusive, or nil if there are none.
+func (class *tCharClass) intersect(start, end rune) *tCharClass {
+	var result tCharClass
+	for _, r := range class.Ranges {
+		from, to := maxRune(r.Start, start), minRune(r.Start+rune(r.Size)-1, end)
+		if from > to {
+			continue
+		}
+		result.Ranges = append(result.Ranges, newCharClassRange(from, to))
+		result.TotalSize += int32(to - from + 1)
+	}
+	if result.TotalSize == 0 {
+		return nil
+	}
+	return &result
+}
+
+// intersectClass returns the runes in both class and other, or nil if there are none.
+func (class *tCharClass) intersectClass(other *tCharClass) *tCharClass {
+	var result tCharClass
+	i, j := 0, 0
+	for i < len(class.Ranges) && j < len(other.Ranges) {
+		a, b := class.Ranges[i], other.Ranges[j]
+		aEnd, bEnd := a.Start+rune(a.Size)-1, b.Start+rune(b.Size)-1
+		if from, to := maxRune(a.Start, b.Start), minRune(aEnd, bEnd); from <= to {
+			result.Ranges = append(result.Ranges, newCharClassRange(from, to))
+			result.TotalSize += int32(to - from + 1)
+		}
+		// Move past whichever range ends first.
+		if aEnd < bEnd {
+			i++
+		} else {
+			j++
+		}
+	}
+	if result.TotalSize == 0 {
+		return nil
+	}
+	return &result
+}
+
+// subset returns a class of size runes of class picked at random, or class itself if it is no larger.
+func (class *tCharClass) subset(size int, rng *rand.Rand) *tCharClass {
+	if int32(size) >= class.TotalSize {
+		return class
+	}
+
+	// Floyd's algorithm, to avoid listing every rune of large classes like ".".
+	picked := make(map[int32]bool, size)
+	for j := class.TotalSize - int32(size); j < class.TotalSize; j++ {
+		i := rng.Int31n(j + 1)
+		if picked[i] {
+			i = j
+		}
+		picked[i] = true
+	}
+
+	runes := make([]rune, 0, size)
+	for i := range picked {
+		runes = append(runes, class.GetRuneAt(i))
+	}
+	sort.Slice(runes, func(i, j int) bool {
+		return runes[i] < runes[j]
+	})
+
+	result := &tCharClass{TotalSize: int32(size)}
+	for _, r := range runes {
+		result.Ranges = append(result.Ranges, newCharClassRange(r, r))
+	}
+	return result
+}
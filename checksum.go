@@ -0,0 +1,263 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"strings"
+)
+
+/*
+ChecksumScheme is a scheme of check digits that can be substituted into generated strings, see
+GeneratorArgs.ChecksumScheme.
+
+Each scheme requires the expression to only generate certain runes, and a certain number of them, not counting
+separators. This is checked from the expression when the generator is created, but not for values from a
+CaptureGroupHandler or GroupValuePools.
+*/
+type ChecksumScheme int
+
+const (
+	// NoChecksum leaves generated strings unchanged. This is the default.
+	NoChecksum ChecksumScheme = iota
+
+	// Luhn replaces the last digit with the Luhn check digit of the others, as used by credit card numbers.
+	// The expression must generate at least 2 ASCII digits, and otherwise only spaces and hyphens,
+	// e.g. `4\d{3}( \d{4}){3}`.
+	Luhn
+
+	// ISBN13 replaces the last digit with the ISBN-13 check digit of the others. The expression must generate
+	// exactly 13 ASCII digits, and otherwise only spaces and hyphens, e.g. `97[89]-\d{10}`.
+	ISBN13
+
+	// IBAN replaces the 3rd and 4th characters with the IBAN check digits. The expression must generate between
+	// 15 and 34 ASCII digits and uppercase letters, and otherwise only spaces, starting with a country code,
+	// e.g. `DE\d{20}`. The country code and the rest of the account number are not checked against the formats
+	// of actual countries.
+	IBAN
+)
+
+type checksumSchemeInfo struct {
+	// Runes other than separators the expression may generate, and how many of them it must generate.
+	isSignificant          func(r rune) bool
+	minCount, maxCount     int
+	separators             string
+	replaceCheckCharacters func(significant []rune)
+}
+
+var checksumSchemes = map[ChecksumScheme]checksumSchemeInfo{
+	Luhn:   {isASCIIDigit, 2, -1, " -", replaceLuhn},
+	ISBN13: {isASCIIDigit, 13, 13, " -", replaceISBN13},
+	IBAN: {func(r rune) bool {
+		return isASCIIDigit(r) || (r >= 'A' && r <= 'Z')
+	}, 15, 34, " ", replaceIBAN},
+}
+
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// check returns an error if args.ChecksumScheme can't be applied to every string regexp generates.
+func (scheme ChecksumScheme) check(regexp *syntax.Regexp, args *GeneratorArgs) error {
+	if scheme == NoChecksum {
+		return nil
+	}
+	info, ok := checksumSchemes[scheme]
+	if !ok {
+		return generatorError(nil, "invalid ChecksumScheme: %d", scheme)
+	}
+
+	allowed := func(r rune) bool {
+		return info.isSignificant(r) || strings.ContainsRune(info.separators, r)
+	}
+	for _, r := range mergeCharClassRanges(collectAlphabet(regexp.Simplify(), args, nil)) {
+		for c := r.Start; c < r.Start+rune(r.Size); c++ {
+			if !allowed(c) {
+				return generatorError(nil, "/%s/ can generate %q, which ChecksumScheme %d doesn't allow", regexp, c, scheme)
+			}
+		}
+	}
+	for _, c := range args.SegmentSeparator {
+		if !allowed(c) {
+			return generatorError(nil, "SegmentSeparator contains %q, which ChecksumScheme %d doesn't allow", c, scheme)
+		}
+	}
+
+	min, max := significantCount(regexp, args, info.isSignificant)
+	if min < info.minCount || (info.maxCount >= 0 && max > info.maxCount) {
+		return generatorError(nil, "/%s/ generates between %d and %d characters for ChecksumScheme %d, which needs %d to %d",
+			regexp, min, max, scheme, info.minCount, info.maxCount)
+	}
+	return nil
+}
+
+// significantCount returns the minimum and maximum number of significant runes in strings generated from regexp.
+// Character classes are counted as significant if any of their runes is.
+func significantCount(regexp *syntax.Regexp, args *GeneratorArgs, isSignificant func(r rune) bool) (min, max int) {
+	switch regexp.Op {
+	case syntax.OpLiteral:
+		for _, r := range regexp.Rune {
+			if isSignificant(r) {
+				min++
+			}
+		}
+		return min, min
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		// Only ASCII classes get this far, so this loop is short.
+		ranges := collectAlphabet(regexp, args, nil)
+		significant, separator := false, false
+		for _, r := range ranges {
+			for c := r.Start; c < r.Start+rune(r.Size); c++ {
+				if isSignificant(c) {
+					significant = true
+				} else {
+					separator = true
+				}
+			}
+		}
+		if !significant {
+			return 0, 0
+		}
+		if separator {
+			return 0, 1
+		}
+		return 1, 1
+	case syntax.OpCapture:
+		return significantCount(regexp.Sub[0], args, isSignificant)
+	case syntax.OpConcat:
+		for _, sub := range regexp.Sub {
+			subMin, subMax := significantCount(sub, args, isSignificant)
+			min += subMin
+			max += subMax
+		}
+		return min, max
+	case syntax.OpAlternate:
+		for i, sub := range regexp.Sub {
+			subMin, subMax := significantCount(sub, args, isSignificant)
+			if i == 0 || subMin < min {
+				min = subMin
+			}
+			if subMax > max {
+				max = subMax
+			}
+		}
+		return min, max
+	case syntax.OpQuest, syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		subMin, subMax := significantCount(regexp.Sub[0], args, isSignificant)
+		repeatMin, repeatMax := repeatBounds(regexp, args)
+		return subMin * repeatMin, subMax * repeatMax
+	}
+	return 0, 0
+}
+
+// repeatBounds returns the minimum and maximum number of times a repetition's sub-expression is generated.
+func repeatBounds(regexp *syntax.Regexp, args *GeneratorArgs) (min, max int) {
+	switch regexp.Op {
+	case syntax.OpQuest:
+		return 0, 1
+	case syntax.OpStar:
+		min, max = int(args.MinUnboundedRepeatCount), noBound
+	case syntax.OpPlus:
+		min, max = 1, noBound
+	default:
+		min, max = regexp.Min, regexp.Max
+		if min == 0 && max == noBound {
+			min = int(args.MinUnboundedRepeatCount)
+		}
+	}
+	if max == noBound {
+		min, max = args.unboundedRepeatBounds(regexp.Op, min)
+	}
+	return min, max
+}
+
+// apply substitutes the check characters of scheme into s.
+func (scheme ChecksumScheme) apply(s string) string {
+	info, ok := checksumSchemes[scheme]
+	if !ok {
+		return s
+	}
+
+	runes := []rune(s)
+	var significant []rune
+	var positions []int
+	for i, r := range runes {
+		if info.isSignificant(r) {
+			significant = append(significant, r)
+			positions = append(positions, i)
+		}
+	}
+	if len(significant) < info.minCount {
+		// Only possible with values from a CaptureGroupHandler or GroupValuePools.
+		return s
+	}
+
+	info.replaceCheckCharacters(significant)
+	for i, position := range positions {
+		runes[position] = significant[i]
+	}
+	return string(runes)
+}
+
+func replaceLuhn(digits []rune) {
+	sum := 0
+	for i := len(digits) - 2; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		// Double every other digit, starting with the one before the check digit.
+		if (len(digits)-2-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	digits[len(digits)-1] = rune('0' + (10-sum%10)%10)
+}
+
+func replaceISBN13(digits []rune) {
+	sum := 0
+	for i, r := range digits[:12] {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(r-'0')
+	}
+	digits[12] = rune('0' + (10-sum%10)%10)
+}
+
+func replaceIBAN(characters []rune) {
+	characters[2], characters[3] = '0', '0'
+	check := 98 - ibanMod97(characters)
+	characters[2], characters[3] = rune('0'+check/10), rune('0'+check%10)
+}
+
+// ibanMod97 returns the IBAN in characters modulo 97, with the first 4 characters moved to the end and letters
+// replaced by numbers (A = 10, B = 11, ...).
+func ibanMod97(characters []rune) int {
+	mod := 0
+	for _, r := range append(append([]rune(nil), characters[4:]...), characters[:4]...) {
+		if r >= 'A' && r <= 'Z' {
+			mod = (mod*100 + int(r-'A'+10)) % 97
+		} else {
+			mod = (mod*10 + int(r-'0')) % 97
+		}
+	}
+	return mod
+}
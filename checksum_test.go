@@ -0,0 +1,143 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestChecksumScheme(t *testing.T) {
+	t.Parallel()
+
+	digitsOf := func(s string) []rune {
+		var digits []rune
+		for _, r := range s {
+			if r != ' ' && r != '-' {
+				digits = append(digits, r)
+			}
+		}
+		return digits
+	}
+
+	// Independent validators, computed the way they are usually described.
+	luhnValid := func(s string) bool {
+		digits := digitsOf(s)
+		sum := 0
+		for i := range digits {
+			d := int(digits[len(digits)-1-i] - '0')
+			if i%2 == 1 {
+				d *= 2
+				if d > 9 {
+					d -= 9
+				}
+			}
+			sum += d
+		}
+		return sum%10 == 0
+	}
+	isbn13Valid := func(s string) bool {
+		sum := 0
+		for i, r := range digitsOf(s) {
+			sum += int(r-'0') * []int{1, 3}[i%2]
+		}
+		return sum%10 == 0
+	}
+	ibanValid := func(s string) bool {
+		return ibanMod97(digitsOf(s)) == 1
+	}
+
+	for _, tc := range []struct {
+		scheme  ChecksumScheme
+		pattern string
+		valid   func(string) bool
+	}{
+		{Luhn, `4\d{3}( \d{4}){3}`, luhnValid},
+		{Luhn, `\d{2,19}`, luhnValid},
+		{ISBN13, `97[89]-\d-?\d{4}-?\d{4}-?\d`, isbn13Valid},
+		{IBAN, `(DE|GB)\d{2}( ?[A-Z0-9]{4}){3,7}`, ibanValid},
+	} {
+		tc := tc
+		t.Run(tc.pattern, func(t *testing.T) {
+			t.Parallel()
+
+			generator, err := NewGenerator(tc.pattern, &GeneratorArgs{Flags: syntax.Perl, ChecksumScheme: tc.scheme})
+			if err != nil {
+				t.Fatal(err)
+			}
+			compiled, _ := generator.CompiledRegexp()
+
+			for i := 0; i < SampleSize; i++ {
+				s := generator.Generate()
+				if !tc.valid(s) {
+					t.Fatalf("%q should have valid check digits", s)
+				}
+				if compiled.FindString(s) != s {
+					t.Fatalf("%q should match", s)
+				}
+			}
+		})
+	}
+
+	t.Run("Known values", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			scheme   ChecksumScheme
+			body     string
+			expected string
+		}{
+			{Luhn, "4111 1111 1111 1110", "4111 1111 1111 1111"},
+			{ISBN13, "978-0-306-40615-0", "978-0-306-40615-7"},
+			{IBAN, "GB00 WEST 1234 5698 7654 32", "GB82 WEST 1234 5698 7654 32"},
+		} {
+			if actual := tc.scheme.apply(tc.body); actual != tc.expected {
+				t.Fatalf("%q should be %q, was %q", tc.body, tc.expected, actual)
+			}
+		}
+	})
+
+	t.Run("Rejects incompatible expressions", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			scheme  ChecksumScheme
+			pattern string
+		}{
+			{Luhn, `\d`},
+			{Luhn, `\d{4}x`},
+			{ISBN13, `\d{12}`},
+			{ISBN13, `\d{13}-?\d?`},
+			{ISBN13, `\d+`},
+			{IBAN, `DE\d{10}`},
+			{IBAN, `de\d{20}`},
+			{ChecksumScheme(42), `\d{20}`},
+		} {
+			_, err := NewGenerator(tc.pattern, &GeneratorArgs{Flags: syntax.Perl, ChecksumScheme: tc.scheme})
+			if err == nil {
+				t.Fatalf("/%s/ should be rejected for ChecksumScheme %d", tc.pattern, tc.scheme)
+			}
+		}
+
+		_, err := NewGenerator(`[0-9]{16}`, &GeneratorArgs{ChecksumScheme: Luhn, SegmentSeparator: "/"})
+		if err == nil || !strings.Contains(err.Error(), "SegmentSeparator") {
+			t.Fatalf("should reject the separator, was %v", err)
+		}
+	})
+}
@@ -0,0 +1,420 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math"
+	"math/big"
+	"regexp/syntax"
+	"unicode"
+)
+
+/*
+NumMatches returns the number of different ways the generator can generate a string: the number of strings it
+can generate, if each can only be generated one way. It is an upper bound otherwise, e.g. `a?a?` is counted as 4
+ways to generate 3 strings. Unbounded repetitions are counted up to MaxUnboundedRepeatCount.
+
+Values from a CaptureGroupHandler are not counted, but values from GroupValuePools are. Scheduled generators
+count the ways of the generators they delegate to. Counts of more than maxCountBits bits, e.g. of `(.*)*`, would
+take too long to compute exactly, so NumMatches returns nil for them.
+*/
+func (gen *internalGenerator) NumMatches() *big.Int {
+	if gen.regexp == nil {
+		return sumScheduled(gen.scheduled, Generator.NumMatches)
+	}
+	if log2Matches(gen.regexp, gen.args) > maxCountBits {
+		return nil
+	}
+	return countMatches(gen.regexp, gen.args)
+}
+
+// Number of bits of the largest count NumMatches computes.
+const maxCountBits = 1 << 16
+
+func countMatches(regexp *syntax.Regexp, args *GeneratorArgs) *big.Int {
+	switch regexp.Op {
+	case syntax.OpNoMatch:
+		return big.NewInt(0)
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return big.NewInt(int64(classCount(generatedClass(regexp, args), args)))
+	case syntax.OpLiteral:
+		count := big.NewInt(1)
+		if regexp.Flags&syntax.FoldCase != 0 {
+			for _, r := range regexp.Rune {
+				count.Mul(count, big.NewInt(int64(len(caseVariants(r)))))
+			}
+		}
+		return count
+	case syntax.OpCapture:
+		if pool, ok := args.GroupValuePools[regexp.Name]; ok && regexp.Name != "" {
+			distinct := map[string]bool{}
+			for _, value := range pool {
+				distinct[value] = true
+			}
+			return big.NewInt(int64(len(distinct)))
+		}
+		return countMatches(regexp.Sub[0], args)
+	case syntax.OpConcat:
+		count := big.NewInt(1)
+		for _, sub := range regexp.Sub {
+			count.Mul(count, countMatches(sub, args))
+		}
+		return count
+	case syntax.OpAlternate:
+		count := big.NewInt(0)
+		for _, sub := range regexp.Sub {
+			count.Add(count, countMatches(sub, args))
+		}
+		return count
+	case syntax.OpQuest, syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		// Sum of sub^k for each number of repetitions k.
+		sub := countMatches(regexp.Sub[0], args)
+		min, max := repeatBounds(regexp, args)
+		count := big.NewInt(0)
+		power := new(big.Int).Exp(sub, big.NewInt(int64(min)), nil)
+		for k := min; k <= max; k++ {
+			count.Add(count, power)
+			power.Mul(power, sub)
+		}
+		return count
+	}
+	// Empty matches and assertions generate a single string.
+	return big.NewInt(1)
+}
+
+// log2Matches is like countMatches, but returns the base 2 logarithm of the count, which is cheap to compute even
+// for counts too large to hold, e.g. of `(.*)*`. It returns -Inf for expressions that match nothing.
+func log2Matches(regexp *syntax.Regexp, args *GeneratorArgs) float64 {
+	switch regexp.Op {
+	case syntax.OpNoMatch:
+		return math.Inf(-1)
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return math.Log2(float64(classCount(generatedClass(regexp, args), args)))
+	case syntax.OpLiteral:
+		log2Count := 0.0
+		if regexp.Flags&syntax.FoldCase != 0 {
+			for _, r := range regexp.Rune {
+				log2Count += math.Log2(float64(len(caseVariants(r))))
+			}
+		}
+		return log2Count
+	case syntax.OpCapture:
+		if _, ok := args.GroupValuePools[regexp.Name]; ok && regexp.Name != "" {
+			return math.Log2(float64(countMatches(regexp, args).Int64()))
+		}
+		return log2Matches(regexp.Sub[0], args)
+	case syntax.OpConcat:
+		log2Count := 0.0
+		for _, sub := range regexp.Sub {
+			log2Count += log2Matches(sub, args)
+		}
+		return log2Count
+	case syntax.OpAlternate:
+		log2Count := math.Inf(-1)
+		for _, sub := range regexp.Sub {
+			log2Count = log2Sum(log2Count, log2Matches(sub, args))
+		}
+		return log2Count
+	case syntax.OpQuest, syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		min, max := repeatBounds(regexp, args)
+		return log2Repeats(log2Matches(regexp.Sub[0], args), min, max)
+	}
+	return 0
+}
+
+// log2Sum returns log2(2^a + 2^b), without computing 2^a or 2^b, which may overflow.
+func log2Sum(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	if math.IsInf(b, -1) {
+		return a
+	}
+	return a + math.Log1p(math.Exp2(b-a))/math.Ln2
+}
+
+// log2Repeats returns the base 2 logarithm of the sum of sub^k for k from min to max, given log2Sub = log2(sub).
+func log2Repeats(log2Sub float64, min, max int) float64 {
+	switch {
+	case math.IsInf(log2Sub, -1):
+		// 0^0 is 1.
+		if min == 0 {
+			return 0
+		}
+		return log2Sub
+	case log2Sub == 0:
+		return math.Log2(float64(max - min + 1))
+	}
+	// The geometric series sub^min * (sub^n - 1) / (sub - 1), for n = max-min+1 terms.
+	n := float64(max - min + 1)
+	return float64(min)*log2Sub + log2Pow2Minus1(n*log2Sub) - log2Pow2Minus1(log2Sub)
+}
+
+// log2Pow2Minus1 returns log2(2^x - 1) for x > 0, which is x once 2^x is too large for the 1 to matter.
+func log2Pow2Minus1(x float64) float64 {
+	if x > 64 {
+		return x
+	}
+	return math.Log2(math.Exp2(x) - 1)
+}
+
+// relativeWeights returns the weights 2^log2Weights, scaled so that the largest is 1 and none overflows.
+func relativeWeights(log2Weights []float64) []float64 {
+	largest := math.Inf(-1)
+	for _, w := range log2Weights {
+		largest = math.Max(largest, w)
+	}
+	weights := make([]float64, len(log2Weights))
+	for i, w := range log2Weights {
+		if math.IsInf(largest, -1) {
+			// Nothing matches, so any choice will do.
+			weights[i] = 1
+		} else {
+			weights[i] = math.Exp2(w - largest)
+		}
+	}
+	return weights
+}
+
+// classCount returns the number of runes generated from class.
+func classCount(class *tCharClass, args *GeneratorArgs) int32 {
+	if class == nil {
+		return 0
+	}
+	count := class.TotalSize
+	if args.UTF8ByteLengthMix != ([4]float64{}) {
+		weighted := int32(0)
+		for i, widthRange := range utf8WidthRanges {
+			if subclass := class.intersect(widthRange[0], widthRange[1]); subclass != nil && args.UTF8ByteLengthMix[i] > 0 {
+				weighted += subclass.TotalSize
+			}
+		}
+		// Classes without weighted widths generate every rune.
+		if weighted > 0 {
+			count = weighted
+		}
+	}
+	if args.ClassAlphabetSize > 0 && int32(args.ClassAlphabetSize) < count {
+		count = int32(args.ClassAlphabetSize)
+	}
+	return count
+}
+
+/*
+EstimateCollisions returns the expected number of duplicates generated while generating n distinct strings, e.g. to
+pick the number of attempts for unique generation, and whether the generator can generate n distinct strings at
+all, according to NumMatches.
+
+The estimate assumes every string is equally likely, which is rarely the case: e.g. `a|b+` generates "a" half of
+the time. Treat it as a lower bound.
+*/
+func (gen *internalGenerator) EstimateCollisions(n int) (expectedRetries float64, feasible bool) {
+	matches := gen.NumMatches()
+	if matches == nil {
+		// Too many to count, so n strings are as good as certain to be distinct.
+		return 0, true
+	}
+	if matches.Cmp(big.NewInt(int64(n))) < 0 {
+		return math.Inf(1), false
+	}
+
+	total, _ := new(big.Float).SetInt(matches).Float64()
+	if total > 1<<53 {
+		// Birthday approximation, since total-i can't be told from total.
+		return float64(n) * float64(n-1) / (2 * total), true
+	}
+
+	// Drawing the (i+1)th distinct string takes total/(total-i) draws on average.
+	for i := 0; i < n; i++ {
+		expectedRetries += total/(total-float64(i)) - 1
+	}
+	return expectedRetries, true
+}
+
+/*
+Cardinality returns the number of distinct strings of at most maxLen runes the generator's expression matches,
+without enumerating them, e.g. to check Enumerate won't take too long. Unlike NumMatches, it counts what the
+expression matches rather than what the generator generates, so repetitions aren't limited by
+MaxUnboundedRepeatCount, and repetitions of expressions matching the empty string aren't counted twice.
+
+Identical alternation branches are only counted once, but strings the expression matches more than one way are
+counted once per way, so the count is an upper bound for ambiguous expressions: ones with alternation branches
+matching the same strings, e.g. `a|[ab]`, and ones with concatenations splitting a string more than one way, e.g.
+`a?a?`, which is counted as 4 strings but matches 3. Assertions aren't accounted for either. Scheduled
+generators add up the counts of the generators they delegate to, an upper bound if those generate the same
+strings. It returns nil for expressions with backreferences, like Enumerate, and scheduled generators delegating
+to them.
+*/
+func (gen *internalGenerator) Cardinality(maxLen int) *big.Int {
+	if gen.regexp == nil {
+		return sumScheduled(gen.scheduled, func(g Generator) *big.Int { return g.Cardinality(maxLen) })
+	}
+	if gen.args != nil && gen.args.backreferences {
+		return nil
+	}
+
+	total := big.NewInt(0)
+	if maxLen < 0 {
+		return total
+	}
+	for _, count := range countByLength(gen.regexp, maxLen) {
+		total.Add(total, count)
+	}
+	return total
+}
+
+// sumScheduled returns the sum of count for the generators a scheduled generator delegates to, or nil if count
+// returns nil for any of them.
+func sumScheduled(generators []Generator, count func(Generator) *big.Int) *big.Int {
+	total := big.NewInt(0)
+	for _, generator := range generators {
+		n := count(generator)
+		if n == nil {
+			return nil
+		}
+		total.Add(total, n)
+	}
+	return total
+}
+
+// countByLength returns the number of strings regexp matches of each length in runes, from 0 to maxLen.
+func countByLength(regexp *syntax.Regexp, maxLen int) []*big.Int {
+	counts := zeroCounts(maxLen)
+	switch regexp.Op {
+	case syntax.OpNoMatch:
+	case syntax.OpLiteral:
+		if len(regexp.Rune) <= maxLen {
+			count := counts[len(regexp.Rune)].SetInt64(1)
+			if regexp.Flags&syntax.FoldCase != 0 {
+				for _, r := range regexp.Rune {
+					count.Mul(count, big.NewInt(int64(len(caseVariants(r)))))
+				}
+			}
+		}
+	case syntax.OpCharClass:
+		if maxLen >= 1 {
+			counts[1].SetInt64(int64(runeCount(regexp.Rune)))
+		}
+	case syntax.OpAnyChar:
+		if maxLen >= 1 {
+			counts[1].SetInt64(int64(runeCount([]rune{0, unicode.MaxRune})))
+		}
+	case syntax.OpAnyCharNotNL:
+		if maxLen >= 1 {
+			counts[1].SetInt64(int64(runeCount([]rune{0, unicode.MaxRune})) - 1)
+		}
+	case syntax.OpCapture:
+		return countByLength(regexp.Sub[0], maxLen)
+	case syntax.OpConcat:
+		counts[0].SetInt64(1)
+		for _, sub := range regexp.Sub {
+			counts = convolveCounts(counts, countByLength(sub, maxLen))
+		}
+	case syntax.OpAlternate:
+		seen := map[string]bool{}
+		for _, sub := range regexp.Sub {
+			if seen[sub.String()] {
+				continue
+			}
+			seen[sub.String()] = true
+			for length, count := range countByLength(sub, maxLen) {
+				counts[length].Add(counts[length], count)
+			}
+		}
+	case syntax.OpQuest, syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		min, max := regexp.Min, regexp.Max
+		switch regexp.Op {
+		case syntax.OpQuest:
+			min, max = 0, 1
+		case syntax.OpStar:
+			min, max = 0, noBound
+		case syntax.OpPlus:
+			min, max = 1, noBound
+		}
+		sub := countByLength(regexp.Sub[0], maxLen)
+		if sub[0].Sign() != 0 {
+			// Repeating the empty string k times matches the same strings as repeating the rest up to k times.
+			sub[0].SetInt64(0)
+			min = 0
+		}
+		// Without the empty string, more than maxLen repetitions don't fit.
+		if max == noBound || max > maxLen {
+			max = maxLen
+		}
+
+		power := zeroCounts(maxLen)
+		power[0].SetInt64(1)
+		for k := 0; k <= max; k++ {
+			if k > 0 {
+				power = convolveCounts(power, sub)
+			}
+			if k >= min {
+				for length, count := range power {
+					counts[length].Add(counts[length], count)
+				}
+			}
+		}
+	default:
+		// Empty matches and assertions match the empty string.
+		counts[0].SetInt64(1)
+	}
+	return counts
+}
+
+func zeroCounts(maxLen int) []*big.Int {
+	counts := make([]*big.Int, maxLen+1)
+	for i := range counts {
+		counts[i] = new(big.Int)
+	}
+	return counts
+}
+
+// convolveCounts returns the number of concatenations of strings counted by a and b of each length.
+func convolveCounts(a, b []*big.Int) []*big.Int {
+	result := zeroCounts(len(a) - 1)
+	product := new(big.Int)
+	for i, x := range a {
+		if x.Sign() == 0 {
+			continue
+		}
+		for j := 0; i+j < len(result); j++ {
+			result[i+j].Add(result[i+j], product.Mul(x, b[j]))
+		}
+	}
+	return result
+}
+
+// runeCount returns the number of runes in the ranges of a character class that can be encoded in UTF-8.
+func runeCount(ranges []rune) int {
+	count := 0
+	for i := 0; i < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		count += int(hi-lo) + 1
+		// Surrogate halves can't be encoded in UTF-8.
+		if lo <= 0xDFFF && hi >= 0xD800 {
+			if lo < 0xD800 {
+				lo = 0xD800
+			}
+			if hi > 0xDFFF {
+				hi = 0xDFFF
+			}
+			count -= int(hi-lo) + 1
+		}
+	}
+	return count
+}
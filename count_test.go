@@ -0,0 +1,164 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math"
+	"math/big"
+	"regexp/syntax"
+	"testing"
+)
+
+func TestNumMatches(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern  string
+		args     *GeneratorArgs
+		expected int64
+	}{
+		{`abc`, nil, 1},
+		{`[ab]{2}`, nil, 4},
+		{`a|b|c`, nil, 3},
+		{`(foo|bar)?`, nil, 3},
+		{`x*`, &GeneratorArgs{MaxUnboundedRepeatCount: 3}, 4},
+		{`[0-9]{2,3}-(a|b)`, nil, 2200},
+		{`(?P<g>\w+)-[ab]`, &GeneratorArgs{Flags: syntax.Perl, GroupValuePools: map[string][]string{"g": {"x", "y", "x"}}}, 4},
+		{`[a-z]`, &GeneratorArgs{ClassAlphabetSize: 5}, 5},
+		{`[aé€]`, &GeneratorArgs{UTF8ByteLengthMix: [4]float64{1, 1, 0, 0}}, 2},
+	} {
+		generator, err := NewGenerator(tc.pattern, tc.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := generator.NumMatches(); actual.Cmp(big.NewInt(tc.expected)) != 0 {
+			t.Fatalf("/%s/ should have %d matches, had %s", tc.pattern, tc.expected, actual)
+		}
+	}
+
+	generator, _ := NewGenerator(`.{10}`, nil)
+	if generator.NumMatches().BitLen() < 200 {
+		t.Fatal("should count large languages")
+	}
+}
+
+func TestEstimateCollisions(t *testing.T) {
+	t.Parallel()
+
+	generator, err := NewGenerator(`[ab]{2}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Collecting all 4 strings takes 4/4 + 4/3 + 4/2 + 4/1 draws on average.
+	retries, feasible := generator.EstimateCollisions(4)
+	if !feasible || math.Abs(retries-(4.0/3+2+4-3)) > 1e-9 {
+		t.Fatalf("should expect 13/3 retries, was %v", retries)
+	}
+	if retries, feasible := generator.EstimateCollisions(1); !feasible || retries != 0 {
+		t.Fatalf("should expect no retries for a single string, was %v", retries)
+	}
+	if _, feasible := generator.EstimateCollisions(5); feasible {
+		t.Fatal("should not be feasible")
+	}
+
+	generator, _ = NewGenerator(`[0-9a-f]{32}`, nil)
+	if retries, feasible := generator.EstimateCollisions(1000); !feasible || retries <= 0 || retries > 1e-30 {
+		t.Fatalf("should expect almost no retries, was %v", retries)
+	}
+}
+
+func TestCardinality(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern  string
+		maxLen   int
+		expected int64
+	}{
+		{`[ab]{2}`, 5, 4},
+		{`a|b|c`, 5, 3},
+		{`a{0,3}`, 5, 4},
+		{`a{0,3}`, 2, 3},
+		{`a*`, 10, 11},
+		{`(a|b|)*`, 2, 7},
+		{`(ab|ab)`, 5, 1},
+		{`(?i)ab`, 5, 4},
+		{`x{2,}`, 1, 0},
+		{`.`, 1, 0x10FFFF + 1 - 0x800 - 1},
+	} {
+		generator, err := NewGenerator(tc.pattern, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := generator.Cardinality(tc.maxLen); actual.Cmp(big.NewInt(tc.expected)) != 0 {
+			t.Errorf("/%s/ should have %d matches up to %d, had %s", tc.pattern, tc.expected, tc.maxLen, actual)
+		}
+	}
+
+	t.Run("Agrees with Enumerate", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`((a|b)(a|b)){1,3}`, `[0-9]{1,2}(x|yz)?`, `(a|bc)+d*`} {
+			generator, _ := NewGenerator(pattern, nil)
+			if actual, expected := generator.Cardinality(5), len(generator.Enumerate(5)); actual.Cmp(big.NewInt(int64(expected))) != 0 {
+				t.Errorf("/%s/ should have %d matches, had %s", pattern, expected, actual)
+			}
+		}
+	})
+
+	t.Run("Upper bound for ambiguous expressions", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			pattern     string
+			cardinality int64
+			enumerated  int
+		}{
+			{`a?a?`, 4, 3},
+			{`a*a*`, 15, 5},
+			{`(a|ab)b?`, 4, 3},
+		} {
+			generator, _ := NewGenerator(tc.pattern, nil)
+			if actual := generator.Cardinality(4); actual.Cmp(big.NewInt(tc.cardinality)) != 0 {
+				t.Errorf("/%s/ should be counted as %d, was %s", tc.pattern, tc.cardinality, actual)
+			}
+			if actual := len(generator.Enumerate(4)); actual != tc.enumerated {
+				t.Errorf("/%s/ should enumerate %d strings, enumerated %d", tc.pattern, tc.enumerated, actual)
+			}
+		}
+	})
+
+	t.Run("Counts large languages", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`[a-z]*`, nil)
+		if generator.Cardinality(100).BitLen() < 400 {
+			t.Fatal("should count large languages")
+		}
+	})
+
+	t.Run("Too many to count", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`(.*)*`, nil)
+		if matches := generator.NumMatches(); matches != nil {
+			t.Fatal("should be nil:", matches.BitLen())
+		}
+	})
+}
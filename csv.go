@@ -0,0 +1,43 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"encoding/csv"
+	"strings"
+)
+
+// FormatCSV formats values as a single CSV record, without a trailing line break.
+// Fields containing commas, quotes or line breaks are quoted, and quotes inside them doubled, as in RFC 4180.
+func FormatCSV(values []string) string {
+	var result strings.Builder
+	w := csv.NewWriter(&result)
+	// Writing to a strings.Builder cannot fail.
+	_ = w.Write(values)
+	w.Flush()
+	return strings.TrimSuffix(result.String(), "\n")
+}
+
+// GenerateCSVRow generates a string from each of columns, and formats them as a CSV record with FormatCSV.
+func GenerateCSVRow(columns []Generator) string {
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		values[i] = column.Generate()
+	}
+	return FormatCSV(values)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatCSV(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		values   []string
+		expected string
+	}{
+		{[]string{"a", "b"}, `a,b`},
+		{[]string{"a,b", "c"}, `"a,b",c`},
+		{[]string{`say "hi"`}, `"say ""hi"""`},
+		{[]string{"line\nbreak", ""}, "\"line\nbreak\","},
+	} {
+		if actual := FormatCSV(tc.values); actual != tc.expected {
+			t.Fatalf("FormatCSV(%q) should be %q, was %q", tc.values, tc.expected, actual)
+		}
+	}
+}
+
+func TestGenerateCSVRow(t *testing.T) {
+	t.Parallel()
+
+	name, _ := NewGenerator(`[a-z]{1,5}(, [a-z]{1,5})?`, nil)
+	quote, _ := NewGenerator(`"[a-z]{3}" ?"?`, nil)
+	number, _ := NewGenerator(`[0-9]{3}`, nil)
+	columns := []Generator{name, quote, number}
+
+	for i := 0; i < SampleSize; i++ {
+		row := GenerateCSVRow(columns)
+
+		// Round-trip through a CSV reader to check the escaping.
+		records, err := csv.NewReader(strings.NewReader(row)).ReadAll()
+		if err != nil {
+			t.Fatalf("%q is not valid CSV: %v", row, err)
+		}
+		if len(records) != 1 || len(records[0]) != 3 {
+			t.Fatalf("%q should be a single record with 3 fields, was %q", row, records)
+		}
+		if !reflect.DeepEqual(FormatCSV(records[0]), row) {
+			t.Fatalf("%q did not round-trip", row)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+// DataKind is a type generated strings can be required to parse as, see GenerateParsableAs.
+type DataKind int
+
+const (
+	// Int32 is a base 10 integer that fits in an int32, as parsed by strconv.ParseInt.
+	Int32 DataKind = iota
+	// Int64 is a base 10 integer that fits in an int64, as parsed by strconv.ParseInt.
+	Int64
+	// Float64 is a finite number as parsed by strconv.ParseFloat.
+	Float64
+	// IPv4 is a dotted decimal IPv4 address, e.g. 192.168.0.1.
+	IPv4
+	// Email is a bare email address as parsed by net/mail, e.g. gopher@example.com.
+	Email
+)
+
+type dataKindInfo struct {
+	name string
+	// Sets of runes the generator must be able to emit at least one of, to be able to generate the kind at all.
+	required []string
+	valid    func(s string) bool
+}
+
+var dataKinds = map[DataKind]dataKindInfo{
+	Int32: {"Int32", []string{"0123456789"}, func(s string) bool {
+		_, err := strconv.ParseInt(s, 10, 32)
+		return err == nil
+	}},
+	Int64: {"Int64", []string{"0123456789"}, func(s string) bool {
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	}},
+	Float64: {"Float64", []string{"0123456789"}, func(s string) bool {
+		f, err := strconv.ParseFloat(s, 64)
+		return err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+	}},
+	IPv4: {"IPv4", []string{"0123456789", "."}, func(s string) bool {
+		// ParseIP also accepts IPv4-mapped IPv6 addresses.
+		return strings.Count(s, ".") == 3 && !strings.Contains(s, ":") && net.ParseIP(s) != nil
+	}},
+	Email: {"Email", []string{"@"}, func(s string) bool {
+		address, err := mail.ParseAddress(s)
+		return err == nil && address.Address == s
+	}},
+}
+
+func (kind DataKind) String() string {
+	if info, ok := dataKinds[kind]; ok {
+		return info.name
+	}
+	return "DataKind(" + strconv.Itoa(int(kind)) + ")"
+}
+
+/*
+GenerateParsableAs generates strings until one is a valid instance of kind, making at most maxAttempts attempts.
+
+It returns an error without generating anything if the generator's alphabet lacks runes every instance of kind
+needs, e.g. digits for Int32.
+*/
+func (gen *internalGenerator) GenerateParsableAs(kind DataKind, maxAttempts int) (string, error) {
+	info, ok := dataKinds[kind]
+	if !ok {
+		return "", generatorError(nil, "invalid DataKind: %d", kind)
+	}
+
+	ranges := gen.alphabetRanges()
+	for _, runes := range info.required {
+		if !rangesContainAny(ranges, runes) {
+			return "", generatorError(nil, "/%s/ can never generate %s: it can't generate any of %q", gen, kind, runes)
+		}
+	}
+
+	result, err := gen.GenerateSatisfying(info.valid, RetryPolicy{MaxAttempts: maxAttempts})
+	if err != nil {
+		return "", generatorError(err, "failed to generate %s from /%s/", kind, gen)
+	}
+	return result, nil
+}
+
+// rangesContainAny reports whether any rune of runes is in the sorted ranges.
+func rangesContainAny(ranges []tCharClassRange, runes string) bool {
+	for _, c := range runes {
+		for _, r := range ranges {
+			if c < r.Start {
+				break
+			}
+			if c < r.Start+rune(r.Size) {
+				return true
+			}
+		}
+	}
+	return false
+}
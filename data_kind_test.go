@@ -0,0 +1,119 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestGenerateParsableAs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Generates valid instances", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			pattern string
+			kind    DataKind
+		}{
+			{`[1-9]\d{0,11}`, Int32},
+			{`-?\d{1,20}`, Int64},
+			{`\d{1,3}(\.\d{1,3})?(e[+-]?\d{1,3})?`, Float64},
+			{`\d{1,3}(\.\d{1,3}){3}`, IPv4},
+			{`[a-z]{1,5}[@.][a-z]{1,5}(\.com)?`, Email},
+		} {
+			generator, err := NewGenerator(tc.pattern, &GeneratorArgs{Flags: syntax.Perl})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 100; i++ {
+				s, err := generator.GenerateParsableAs(tc.kind, 1000)
+				if err != nil {
+					t.Fatalf("/%s/ as %s: %v", tc.pattern, tc.kind, err)
+				}
+				if !dataKinds[tc.kind].valid(s) {
+					t.Fatalf("%q is not a valid %s", s, tc.kind)
+				}
+			}
+		}
+	})
+
+	t.Run("Validators", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			kind    DataKind
+			s       string
+			isValid bool
+		}{
+			{Int32, "2147483647", true},
+			{Int32, "2147483648", false},
+			{Int64, "2147483648", true},
+			{Int64, "1.5", false},
+			{Float64, "1.5e3", true},
+			{Float64, "1e400", false},
+			{Float64, "NaN", false},
+			{IPv4, "10.0.0.255", true},
+			{IPv4, "10.0.0.256", false},
+			{IPv4, "::ffff:10.0.0.1", false},
+			{Email, "a@b.com", true},
+			{Email, "a.b.com", false},
+			{Email, "A <a@b.com>", false},
+		} {
+			if dataKinds[tc.kind].valid(tc.s) != tc.isValid {
+				t.Fatalf("%s(%q) should be %v", tc.kind, tc.s, tc.isValid)
+			}
+		}
+	})
+
+	t.Run("Fails fast on impossible kinds", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			pattern string
+			kind    DataKind
+		}{
+			{`[a-z]+`, Int32},
+			{`\d+`, IPv4},
+			{`[a-z]+`, Email},
+		} {
+			generator, _ := NewGenerator(tc.pattern, &GeneratorArgs{Flags: syntax.Perl})
+			// Would take a while if every attempt was made.
+			if _, err := generator.GenerateParsableAs(tc.kind, 100000000); err == nil {
+				t.Fatalf("/%s/ as %s: err should not be nil", tc.pattern, tc.kind)
+			}
+		}
+	})
+
+	t.Run("Reports exhausted attempts", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`[1-9]\d{10}`, &GeneratorArgs{Flags: syntax.Perl})
+		if _, err := generator.GenerateParsableAs(Int32, 10); err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if _, err := generator.GenerateParsableAs(DataKind(42), 10); err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if DataKind(42).String() != "DataKind(42)" {
+			t.Fatal("should be equal")
+		}
+	})
+}
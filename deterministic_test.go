@@ -0,0 +1,86 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestDeterministic(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Generates first choices", func(t *testing.T) {
+		t.Parallel()
+
+		for pattern, expected := range map[string]string{
+			`foo|bar`:          "foo",
+			`a{2,5}b?c*d+`:     "aabd",
+			`[0-9a-f]{3}`:      "000",
+			`(?i)hello`:        "HELLO",
+			`(\w+)?@.`:         "0@!",
+			`[^a]x{0,1}`:       "!",
+			`(?:x|y)|[b-z]{2}`: "x",
+		} {
+			generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, Deterministic: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 10; i++ {
+				if s := generator.Generate(); s != expected {
+					t.Fatalf("/%s/ should generate %q, generated %q", pattern, expected, s)
+				}
+			}
+		}
+	})
+
+	t.Run("Ignores seed", func(t *testing.T) {
+		t.Parallel()
+
+		pattern := `[a-z]{3,8}(foo|bar)*\d?`
+		first, second := int64(1), int64(2)
+		a, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, Deterministic: true, Seed: &first})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, Deterministic: true, Seed: &second})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sa, sb := a.Generate(), b.Generate(); sa != "aaa0" || sb != sa {
+			t.Fatal("should be equal:", sa, sb, "aaa0")
+		}
+	})
+
+	t.Run("Takes precedence over random settings", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(a|b)?c{1,4}`, &GeneratorArgs{
+			Deterministic:         true,
+			Uniform:               true,
+			OptionalProbabilities: map[int]float64{0: 0},
+			RepeatCountSampler:    GeometricRepeat(0.5),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := generator.Generate(); s != "ac" {
+			t.Fatal("should be equal:", s, "ac")
+		}
+	})
+}
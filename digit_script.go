@@ -0,0 +1,45 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+// DigitScript selects the script that digits in character classes (e.g. `\d` or `[0-9]`) are generated in.
+type DigitScript int
+
+const (
+	// ASCIIDigits generates 0-9. This is the default.
+	ASCIIDigits DigitScript = iota
+	// ArabicIndicDigits generates ٠-٩ (U+0660-U+0669).
+	ArabicIndicDigits
+	// DevanagariDigits generates ०-९ (U+0966-U+096F).
+	DevanagariDigits
+)
+
+// Code point of the zero digit of each script.
+var digitScriptZeros = map[DigitScript]rune{
+	ASCIIDigits:       '0',
+	ArabicIndicDigits: '٠',
+	DevanagariDigits:  '०',
+}
+
+// convert maps an ASCII digit to the same digit in script. Other runes are returned unchanged.
+func (script DigitScript) convert(r rune) rune {
+	if r < '0' || r > '9' {
+		return r
+	}
+	return digitScriptZeros[script] + (r - '0')
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestDigitScript(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Arabic-Indic", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{
+			Flags:       syntax.Perl,
+			DigitScript: ArabicIndicDigits,
+		}
+		GeneratesStringMatching(t, args, `\d{5}`, `^\p{Nd}{5}$`)
+		GeneratesStringMatching(t, args, `1[0-9]x`, `^1[٠-٩]x$`)
+	})
+
+	t.Run("Devanagari", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{
+			Flags:       syntax.Perl,
+			DigitScript: DevanagariDigits,
+		}
+		GeneratesStringMatching(t, args, `\d{5}`, `^[०-९]{5}$`)
+	})
+
+	t.Run("Defaults to ASCII", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{Flags: syntax.Perl}, `\d{5}`, `^[0-9]{5}$`)
+	})
+
+	t.Run("Rejects unknown scripts", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewGenerator(`\d`, &GeneratorArgs{DigitScript: DigitScript(42)})
+		if err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
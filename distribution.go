@@ -0,0 +1,140 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Categories runes are counted in by CompareDistributions.
+const (
+	CategoryLower = "lower"
+	CategoryUpper = "upper"
+	CategoryDigit = "digit"
+	CategorySpace = "space"
+	CategoryPunct = "punct"
+	CategoryOther = "other"
+)
+
+// DistReport describes how the strings generated by two generators differ, see CompareDistributions.
+// Fields ending in A describe the first generator, and fields ending in B the second.
+type DistReport struct {
+	// Number of strings generated from each generator.
+	Samples int
+
+	// Number of strings of each length, in runes.
+	LengthsA, LengthsB map[int]int
+	// Mean length of the strings, in runes.
+	MeanLengthA, MeanLengthB float64
+	// Mean size of the strings, in bytes.
+	MeanBytesA, MeanBytesB float64
+
+	// Fraction of runes in each category (CategoryLower, CategoryDigit, ...). Categories without runes are omitted.
+	CategoriesA, CategoriesB map[string]float64
+
+	// Total variation distance between the length distributions, and between the category distributions:
+	// 0 if they are the same, 1 if they have nothing in common.
+	LengthDistance, CategoryDistance float64
+}
+
+// CompareDistributions generates samples strings from each of a and b, and reports how they differ, e.g. to check
+// that a changed expression still generates the same kind of data.
+func CompareDistributions(a, b Generator, samples int) DistReport {
+	report := DistReport{Samples: samples}
+	report.LengthsA, report.MeanLengthA, report.MeanBytesA, report.CategoriesA = sampleDistribution(a, samples)
+	report.LengthsB, report.MeanLengthB, report.MeanBytesB, report.CategoriesB = sampleDistribution(b, samples)
+
+	lengthsA, lengthsB := map[int]float64{}, map[int]float64{}
+	for length, n := range report.LengthsA {
+		lengthsA[length] = float64(n) / float64(samples)
+	}
+	for length, n := range report.LengthsB {
+		lengthsB[length] = float64(n) / float64(samples)
+	}
+	report.LengthDistance = totalVariation(lengthsA, lengthsB)
+
+	categoriesA, categoriesB := map[int]float64{}, map[int]float64{}
+	for i, category := range []string{CategoryLower, CategoryUpper, CategoryDigit, CategorySpace, CategoryPunct, CategoryOther} {
+		categoriesA[i], categoriesB[i] = report.CategoriesA[category], report.CategoriesB[category]
+	}
+	report.CategoryDistance = totalVariation(categoriesA, categoriesB)
+
+	return report
+}
+
+func sampleDistribution(generator Generator, samples int) (lengths map[int]int, meanLength, meanBytes float64,
+	categories map[string]float64) {
+	lengths = map[int]int{}
+	counts := map[string]int{}
+	totalRunes, totalBytes := 0, 0
+	for i := 0; i < samples; i++ {
+		s := generator.Generate()
+		length := utf8.RuneCountInString(s)
+		lengths[length]++
+		totalRunes += length
+		totalBytes += len(s)
+		for _, r := range s {
+			counts[runeCategory(r)]++
+		}
+	}
+
+	categories = map[string]float64{}
+	for category, n := range counts {
+		categories[category] = float64(n) / float64(totalRunes)
+	}
+	if samples > 0 {
+		meanLength = float64(totalRunes) / float64(samples)
+		meanBytes = float64(totalBytes) / float64(samples)
+	}
+	return lengths, meanLength, meanBytes, categories
+}
+
+func runeCategory(r rune) string {
+	switch {
+	case unicode.IsLower(r):
+		return CategoryLower
+	case unicode.IsUpper(r):
+		return CategoryUpper
+	case unicode.IsDigit(r):
+		return CategoryDigit
+	case unicode.IsSpace(r):
+		return CategorySpace
+	case unicode.IsPunct(r) || unicode.IsSymbol(r):
+		return CategoryPunct
+	}
+	return CategoryOther
+}
+
+// totalVariation returns half the sum of the absolute differences between the probabilities in p and q.
+func totalVariation(p, q map[int]float64) float64 {
+	sum := 0.0
+	for k, pk := range p {
+		if pk > q[k] {
+			sum += pk - q[k]
+		} else {
+			sum += q[k] - pk
+		}
+	}
+	for k, qk := range q {
+		if _, ok := p[k]; !ok {
+			sum += qk
+		}
+	}
+	return sum / 2
+}
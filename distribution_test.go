@@ -0,0 +1,66 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompareDistributions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Different lengths", func(t *testing.T) {
+		t.Parallel()
+
+		a, _ := NewGenerator(`[a-z]{5}`, nil)
+		b, _ := NewGenerator(`[a-z]{5,6}`, nil)
+		report := CompareDistributions(a, b, SampleSize)
+
+		if report.Samples != SampleSize || report.LengthsA[5] != SampleSize || report.LengthsA[6] != 0 {
+			t.Fatalf("a should only generate 5 runes, was %v", report.LengthsA)
+		}
+		if report.LengthsB[5]+report.LengthsB[6] != SampleSize || report.LengthsB[6] == 0 {
+			t.Fatalf("b should generate 5 and 6 runes, was %v", report.LengthsB)
+		}
+		if report.MeanLengthA != 5 || report.MeanLengthB <= 5 || report.MeanBytesB != report.MeanLengthB {
+			t.Fatalf("mean lengths should differ, were %v and %v", report.MeanLengthA, report.MeanLengthB)
+		}
+		if math.Abs(report.LengthDistance-float64(report.LengthsB[6])/SampleSize) > 1e-9 {
+			t.Fatalf("length distance should be the fraction of 6 rune strings, was %v", report.LengthDistance)
+		}
+		if report.CategoryDistance != 0 || report.CategoriesA[CategoryLower] != 1 {
+			t.Fatalf("categories should be the same, were %v and %v", report.CategoriesA, report.CategoriesB)
+		}
+	})
+
+	t.Run("Different categories", func(t *testing.T) {
+		t.Parallel()
+
+		a, _ := NewGenerator(`[a-z]{4}`, nil)
+		b, _ := NewGenerator(`[a-z]{2}[0-9]{2}`, nil)
+		report := CompareDistributions(a, b, 100)
+
+		if report.LengthDistance != 0 {
+			t.Fatalf("lengths should be the same, distance was %v", report.LengthDistance)
+		}
+		if report.CategoriesB[CategoryDigit] != 0.5 || report.CategoryDistance != 0.5 {
+			t.Fatalf("half of b's runes should be digits, was %v", report.CategoriesB)
+		}
+	})
+}
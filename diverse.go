@@ -0,0 +1,97 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+// Number of candidates generated per requested sample by DiverseSamples.
+const diverseCandidatesPerSample = 16
+
+/*
+DiverseSamples returns n generated strings that differ from each other as much as possible, e.g. to illustrate what
+an expression generates in documentation.
+
+It generates 16 candidates per sample and picks from them greedily: first the shortest, then repeatedly the
+candidate farthest from all strings picked so far. The distance between two strings is the difference in their
+lengths plus the number of positions their runes differ at, so strings of different lengths or from different
+branches are preferred. If the expression generates fewer than n distinct candidates, the rest of the samples
+are random.
+*/
+func (gen *internalGenerator) DiverseSamples(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	var candidates [][]rune
+	seen := map[string]bool{}
+	for i := 0; i < n*diverseCandidatesPerSample; i++ {
+		s := gen.Generate()
+		if !seen[s] {
+			seen[s] = true
+			candidates = append(candidates, []rune(s))
+		}
+	}
+
+	// Distance from each candidate to the closest sample picked so far.
+	distances := make([]int, len(candidates))
+	picked := make([]bool, len(candidates))
+	next := 0
+	for i, candidate := range candidates {
+		distances[i] = -1
+		if len(candidate) < len(candidates[next]) {
+			next = i
+		}
+	}
+
+	samples := make([]string, 0, n)
+	for len(samples) < n && len(samples) < len(candidates) {
+		picked[next] = true
+		samples = append(samples, string(candidates[next]))
+
+		farthest := -1
+		for i, candidate := range candidates {
+			if picked[i] {
+				continue
+			}
+			if d := sampleDistance(candidate, candidates[next]); distances[i] < 0 || d < distances[i] {
+				distances[i] = d
+			}
+			if farthest < 0 || distances[i] > distances[farthest] {
+				farthest = i
+			}
+		}
+		next = farthest
+	}
+
+	for len(samples) < n {
+		samples = append(samples, gen.Generate())
+	}
+	return samples
+}
+
+// sampleDistance is the difference in length of a and b plus the number of positions they differ at.
+func sampleDistance(a, b []rune) int {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	d := len(b) - len(a)
+	for i := range a {
+		if a[i] != b[i] {
+			d++
+		}
+	}
+	return d
+}
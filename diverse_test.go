@@ -0,0 +1,76 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDiverseSamples(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Covers branches and lengths", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(cat|dog|bird)s{0,3}`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pattern := regexp.MustCompile(`^(cat|dog|bird)s{0,3}$`)
+
+		samples := generator.DiverseSamples(5)
+		if len(samples) != 5 {
+			t.Fatalf("should return 5 samples, returned %d", len(samples))
+		}
+
+		animals := map[string]bool{}
+		shortest, longest := samples[0], samples[0]
+		for _, s := range samples {
+			if !pattern.MatchString(s) {
+				t.Fatalf("%q should match", s)
+			}
+			animals[pattern.FindStringSubmatch(s)[1]] = true
+			if len(s) < len(shortest) {
+				shortest = s
+			}
+			if len(s) > len(longest) {
+				longest = s
+			}
+		}
+		if len(animals) != 3 {
+			t.Fatalf("should include every branch, was %v", samples)
+		}
+		if len(longest)-len(shortest) < 3 {
+			t.Fatalf("should include different lengths, was %v", samples)
+		}
+	})
+
+	t.Run("Falls back to random", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`a|b`, nil)
+		samples := generator.DiverseSamples(4)
+		if len(samples) != 4 || samples[0] == samples[1] {
+			t.Fatalf("should return both strings then random ones, was %v", samples)
+		}
+		if generator.DiverseSamples(0) != nil {
+			t.Fatal("should be nil")
+		}
+	})
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+)
+
+// trimSurroundingDotStar returns a copy of regexp with a leading or trailing ".*" or ".+" limited to max repetitions.
+// regexp itself is not modified.
+func trimSurroundingDotStar(regexp *syntax.Regexp, max int) *syntax.Regexp {
+	if regexp.Op != syntax.OpConcat {
+		if trimmed := trimDotStar(regexp, max); trimmed != nil {
+			return trimmed
+		}
+		return regexp
+	}
+
+	subs := append([]*syntax.Regexp(nil), regexp.Sub...)
+	for _, i := range []int{0, len(subs) - 1} {
+		if trimmed := trimDotStar(subs[i], max); trimmed != nil {
+			subs[i] = trimmed
+		}
+	}
+
+	trimmed := *regexp
+	trimmed.Sub = subs
+	return &trimmed
+}
+
+// trimDotStar returns a repeat of at most max any-characters equivalent to regexp if it is ".*" or ".+",
+// or nil otherwise.
+func trimDotStar(regexp *syntax.Regexp, max int) *syntax.Regexp {
+	if regexp.Op != syntax.OpStar && regexp.Op != syntax.OpPlus {
+		return nil
+	}
+	if op := regexp.Sub[0].Op; op != syntax.OpAnyChar && op != syntax.OpAnyCharNotNL {
+		return nil
+	}
+
+	min := 0
+	if regexp.Op == syntax.OpPlus {
+		min = 1
+	}
+	return &syntax.Regexp{
+		Op:    syntax.OpRepeat,
+		Flags: regexp.Flags,
+		Sub:   []*syntax.Regexp{regexp.Sub[0]},
+		Min:   min,
+		Max:   max,
+	}
+}
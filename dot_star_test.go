@@ -0,0 +1,94 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTrimSurroundingDotStar(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Generates short matches", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`.*ERROR.*`, `.+ERROR.+`, `.*ERROR`, `(?s).*`} {
+			generator, err := NewGenerator(pattern, &GeneratorArgs{
+				Flags:                  syntax.Perl,
+				TrimSurroundingDotStar: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := regexp.MustCompile(`\A(?:` + pattern + `)\z`)
+
+			for i := 0; i < SampleSize; i++ {
+				s := generator.Generate()
+				if !expected.MatchString(s) {
+					t.Fatalf("%q does not match /%s/", s, pattern)
+				}
+				if utf8.RuneCountInString(s) > 2*DefaultSurroundingDotStarMax+len("ERROR") {
+					t.Fatalf("%q is too long", s)
+				}
+			}
+		}
+	})
+
+	t.Run("Respects SurroundingDotStarMax", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`.+ERROR`, &GeneratorArgs{
+			Flags:                  syntax.Perl,
+			TrimSurroundingDotStar: true,
+			SurroundingDotStarMax:  1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			if s := generator.Generate(); utf8.RuneCountInString(s) != len("ERROR")+1 {
+				t.Fatalf("%q should have exactly one filler character", s)
+			}
+		}
+	})
+
+	t.Run("Leaves inner and anchored dot stars alone", func(t *testing.T) {
+		t.Parallel()
+
+		pattern, _ := syntax.Parse(`^.*a.*b.*\z`, syntax.Perl)
+		if trimmed := trimSurroundingDotStar(pattern, 8); trimmed.String() != pattern.String() {
+			t.Fatalf("should be unchanged, was /%s/", trimmed)
+		}
+	})
+
+	t.Run("Does not change the reported expression", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`.*ERROR.*`, &GeneratorArgs{Flags: syntax.Perl, TrimSurroundingDotStar: true})
+		compiled, err := generator.CompiledRegexp()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if compiled.String() != `(?-s:.*ERROR.*)` {
+			t.Fatalf("should be the original expression, was /%s/", compiled)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "regexp/syntax"
+
+// EmitFunc is called with the runes generated for each literal or character class, e.g. "ab" for `ab` or "3" for
+// `\d`, and returns the runes to write in their place.
+type EmitFunc func(runes []rune) []rune
+
+// emitsRunes reports whether the generator for an expression with op emits a chunk passed to OnEmit.
+func emitsRunes(op syntax.Op) bool {
+	switch op {
+	case syntax.OpLiteral, syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	}
+	return false
+}
+
+// createEmitGenerator returns a generator writing what generator generates as transformed by onEmit.
+func createEmitGenerator(generator *internalGenerator, onEmit EmitFunc) *internalGenerator {
+	return &internalGenerator{Name: generator.Name, GenerateFunc: func(state *generatorState) {
+		runes := onEmit([]rune(generator.generateString(state)))
+		if !state.cancelled() {
+			state.out.WriteString(string(runes))
+		}
+	}}
+}
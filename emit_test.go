@@ -0,0 +1,89 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestOnEmit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Uppercases everything", func(t *testing.T) {
+		t.Parallel()
+
+		upper := func(runes []rune) []rune {
+			for i, r := range runes {
+				runes[i] = unicode.ToUpper(r)
+			}
+			return runes
+		}
+		generator, err := NewGenerator(`(foo|bar)-[a-z]{3}\d.`, &GeneratorArgs{Flags: syntax.Perl, OnEmit: upper})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pattern := regexp.MustCompile(`^(FOO|BAR)-[A-Z]{3}\d.$`)
+		for i := 0; i < SampleSize; i++ {
+			s := generator.Generate()
+			if !pattern.MatchString(s) || s != strings.ToUpper(s) {
+				t.Fatalf("should be uppercased: %q", s)
+			}
+		}
+	})
+
+	t.Run("Is called for each chunk", func(t *testing.T) {
+		t.Parallel()
+
+		var chunks []string
+		record := func(runes []rune) []rune {
+			chunks = append(chunks, string(runes))
+			return runes
+		}
+		generator, err := NewGenerator(`ab[c]{2}`, &GeneratorArgs{OnEmit: record})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := generator.Generate(); s != "abcc" {
+			t.Fatal("should be equal:", s, "abcc")
+		}
+		if strings.Join(chunks, ",") != "ab,c,c" {
+			t.Fatal("wrong chunks:", chunks)
+		}
+	})
+
+	t.Run("Replaces chunks", func(t *testing.T) {
+		t.Parallel()
+
+		leet := func(runes []rune) []rune {
+			return []rune(strings.NewReplacer("e", "3", "t", "7").Replace(string(runes)))
+		}
+		generator, err := NewGenerator(`leet|test`, &GeneratorArgs{OnEmit: leet})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 10; i++ {
+			if s := generator.Generate(); s != "l337" && s != "73s7" {
+				t.Fatal("wrong output:", s)
+			}
+		}
+	})
+}
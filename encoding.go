@@ -0,0 +1,49 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"unicode"
+)
+
+// GenerateEncoded generates a string, and returns it encoded with GeneratorArgs.OutputEncoder, or as UTF-8 if
+// no encoder is set.
+func (gen *internalGenerator) GenerateEncoded() ([]byte, error) {
+	s := gen.Generate()
+	if gen.args == nil || gen.args.OutputEncoder == nil {
+		return []byte(s), nil
+	}
+
+	encoded, err := gen.args.OutputEncoder([]rune(s))
+	if err != nil {
+		return nil, generatorError(err, "failed to encode %q", s)
+	}
+	return encoded, nil
+}
+
+// EncodeLatin1 is an OutputEncoder for ISO 8859-1, which maps each rune up to U+00FF to a single byte.
+func EncodeLatin1(runes []rune) ([]byte, error) {
+	encoded := make([]byte, len(runes))
+	for i, r := range runes {
+		if r > unicode.MaxLatin1 {
+			return nil, generatorError(nil, "%U can't be encoded in Latin-1", r)
+		}
+		encoded[i] = byte(r)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateEncoded(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Latin-1", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`[a-zé]{1,8}`, &GeneratorArgs{OutputEncoder: EncodeLatin1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			encoded, err := generator.GenerateEncoded()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, b := range encoded {
+				if (b < 'a' || b > 'z') && b != 0xe9 {
+					t.Fatalf("%x is not in Latin-1 [a-zé]", encoded)
+				}
+			}
+		}
+	})
+
+	t.Run("Unrepresentable runes", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`a€`, &GeneratorArgs{OutputEncoder: EncodeLatin1})
+		if _, err := generator.GenerateEncoded(); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+
+	t.Run("Defaults to UTF-8", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`é€`, nil)
+		encoded, err := generator.GenerateEncoded()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(encoded, []byte("é€")) {
+			t.Fatal("should be equal")
+		}
+	})
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Enumerate returns every string of at most maxLen runes the generator's expression matches, sorted and without
+duplicates, e.g. `a|a` only enumerates "a". Unbounded repetitions are repeated as long as they can fit.
+
+Strings are collected for each sub-expression before they are combined, and discarded as soon as they can't fit, so
+that e.g. `(a|b)(a|b)(a|b)` combines three sets of two strings instead of walking eight paths. Still, the result
+grows exponentially with maxLen for most expressions, so only use it for small ones. Strings failing the assertions
+of the expression are discarded at the end.
+
+Scheduled generators return the strings the generators they delegate to enumerate. It returns nil for expressions
+with backreferences.
+*/
+func (gen *internalGenerator) Enumerate(maxLen int) []string {
+	if gen.regexp == nil {
+		return scheduledEnumeration(gen.scheduled, maxLen)
+	}
+	if gen.args != nil && gen.args.backreferences {
+		return nil
+	}
+
+	full, err := gen.fullRegexp()
+	var result []string
+	for s := range enumerate(gen.regexp, gen.args, maxLen) {
+		if err != nil || full.MatchString(s) {
+			result = append(result, s)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// stringSet is a set of strings, each mapped to its length in runes.
+type stringSet map[string]int
+
+// enumerate returns the strings of at most maxLen runes regexp matches.
+func enumerate(regexp *syntax.Regexp, args *GeneratorArgs, maxLen int) stringSet {
+	set := stringSet{}
+	switch regexp.Op {
+	case syntax.OpNoMatch:
+	case syntax.OpLiteral:
+		if len(regexp.Rune) > maxLen {
+			break
+		}
+		set[""] = 0
+		for _, r := range regexp.Rune {
+			variants := []rune{r}
+			if regexp.Flags&syntax.FoldCase != 0 {
+				variants = caseVariants(r)
+			}
+			next := stringSet{}
+			for s, n := range set {
+				for _, v := range variants {
+					next[s+string(v)] = n + 1
+				}
+			}
+			set = next
+		}
+	case syntax.OpCharClass:
+		addRunes(set, regexp.Rune, maxLen)
+	case syntax.OpAnyChar:
+		addRunes(set, []rune{0, unicode.MaxRune}, maxLen)
+	case syntax.OpAnyCharNotNL:
+		addRunes(set, []rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}, maxLen)
+	case syntax.OpCapture:
+		return enumerate(regexp.Sub[0], args, maxLen)
+	case syntax.OpConcat:
+		// Leave room for the shortest strings of the parts still to come.
+		remaining := make([]int, len(regexp.Sub)+1)
+		for i := len(regexp.Sub) - 1; i >= 0; i-- {
+			min, _ := lengthBounds(regexp.Sub[i], args)
+			remaining[i] = saturatingAdd(remaining[i+1], min)
+		}
+		set[""] = 0
+		for i, sub := range regexp.Sub {
+			set = concatSets(set, enumerate(sub, args, maxLen-remaining[i+1]), maxLen-remaining[i+1])
+			if len(set) == 0 {
+				break
+			}
+		}
+	case syntax.OpAlternate:
+		for _, sub := range regexp.Sub {
+			for s, n := range enumerate(sub, args, maxLen) {
+				set[s] = n
+			}
+		}
+	case syntax.OpQuest, syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		min, max := regexp.Min, regexp.Max
+		switch regexp.Op {
+		case syntax.OpQuest:
+			min, max = 0, 1
+		case syntax.OpStar:
+			min, max = 0, noBound
+		case syntax.OpPlus:
+			min, max = 1, noBound
+		}
+		sub := enumerate(regexp.Sub[0], args, maxLen)
+
+		power := stringSet{"": 0}
+		if min == 0 {
+			set[""] = 0
+		}
+		for k := 1; max == noBound || k <= max; k++ {
+			power = concatSets(power, sub, maxLen)
+			if len(power) == 0 {
+				break
+			}
+			if k >= min {
+				for s, n := range power {
+					set[s] = n
+				}
+			}
+			// Past maxLen repetitions, any string that fits repeats the empty string, so it was already found with
+			// fewer repetitions.
+			if k >= min && k >= maxLen {
+				break
+			}
+		}
+	default:
+		// Empty matches and assertions match the empty string.
+		if maxLen >= 0 {
+			set[""] = 0
+		}
+	}
+	return set
+}
+
+// concatSets returns the concatenations of strings in a and b of at most maxLen runes.
+func concatSets(a, b stringSet, maxLen int) stringSet {
+	result := stringSet{}
+	for s, n := range a {
+		for t, m := range b {
+			if n+m <= maxLen {
+				result[s+t] = n + m
+			}
+		}
+	}
+	return result
+}
+
+// addRunes adds the runes in the ranges of a character class to set, if maxLen leaves room for them.
+func addRunes(set stringSet, ranges []rune, maxLen int) {
+	if maxLen < 1 {
+		return
+	}
+	for i := 0; i < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1]; r++ {
+			// Surrogate halves can't be encoded in UTF-8.
+			if utf8.ValidRune(r) {
+				set[string(r)] = 1
+			}
+			if r == unicode.MaxRune {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"reflect"
+	"regexp/syntax"
+	"testing"
+)
+
+func TestEnumerate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern  string
+		args     *GeneratorArgs
+		maxLen   int
+		expected []string
+	}{
+		{`a|a`, nil, 5, []string{"a"}},
+		{`(a|b)(c|d)`, nil, 5, []string{"ac", "ad", "bc", "bd"}},
+		{`(a|b)(c|d)`, nil, 1, nil},
+		{`a*`, nil, 3, []string{"", "a", "aa", "aaa"}},
+		{`(a|b|)*`, nil, 2, []string{"", "a", "aa", "ab", "b", "ba", "bb"}},
+		{`x{2,}`, nil, 1, nil},
+		{`a{2,3}b?`, nil, 3, []string{"aa", "aaa", "aab"}},
+		{`(?i)ab`, &GeneratorArgs{Flags: syntax.Perl}, 2, []string{"AB", "Ab", "aB", "ab"}},
+		{`[0-2]x`, nil, 2, []string{"0x", "1x", "2x"}},
+		{`a\b[ b]?`, &GeneratorArgs{Flags: syntax.Perl}, 2, []string{"a", "a "}},
+		{`(a)\1`, &GeneratorArgs{Flags: syntax.Perl}, 2, nil},
+	} {
+		generator, err := NewGenerator(tc.pattern, tc.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := generator.Enumerate(tc.maxLen); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("/%s/ should enumerate %q up to %d, was %q", tc.pattern, tc.expected, tc.maxLen, actual)
+		}
+	}
+
+	t.Run("Nested concatenations of alternations", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`((a|b)(a|b)){1,3}`, nil)
+		if actual := len(generator.Enumerate(4)); actual != 4+16 {
+			t.Fatal("should be equal:", actual, 4+16)
+		}
+		generator, _ = NewGenerator(`(a|b|c|d)+((a|b)|(c|d))*`, nil)
+		if actual := len(generator.Enumerate(6)); actual != 4+16+64+256+1024+4096 {
+			t.Fatal("should be equal:", actual, 4+16+64+256+1024+4096)
+		}
+	})
+}
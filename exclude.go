@@ -0,0 +1,126 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"unicode"
+)
+
+// allowedClass returns the runes other than NUL not in excluded.
+func allowedClass(excluded []rune) *tCharClass {
+	class := &tCharClass{}
+	start := rune(1)
+	if excludedClass := runeSetClass(excluded); excludedClass != nil {
+		for _, r := range excludedClass.Ranges {
+			if r.Start > start {
+				class.Ranges = append(class.Ranges, newCharClassRange(start, r.Start-1))
+				class.TotalSize += int32(r.Start - start)
+			}
+			start = r.Start + rune(r.Size)
+		}
+	}
+	if start <= unicode.MaxRune {
+		class.Ranges = append(class.Ranges, newCharClassRange(start, unicode.MaxRune))
+		class.TotalSize += int32(unicode.MaxRune - start + 1)
+	}
+	return class
+}
+
+/*
+excludeRunes returns regexp with the runes not in allowed removed from its character classes and literals. Literal
+runes with some case variants excluded become classes of the others, and ones with none left become empty classes,
+which emptied maps to the nodes of regexp they stand in for. Nodes that don't change are shared with regexp.
+*/
+func excludeRunes(regexp *syntax.Regexp, allowed *tCharClass, emptied map[*syntax.Regexp]*syntax.Regexp) *syntax.Regexp {
+	switch regexp.Op {
+	case syntax.OpCharClass:
+		class := parseCharClass(regexp.Rune).intersectClass(allowed)
+		if class != nil && class.TotalSize == parseCharClass(regexp.Rune).TotalSize {
+			return regexp
+		}
+		return restrictedClass(regexp, class, emptied)
+	case syntax.OpLiteral:
+		return excludeLiteralRunes(regexp, allowed, emptied)
+	}
+
+	var subs []*syntax.Regexp
+	for i, sub := range regexp.Sub {
+		excluded := excludeRunes(sub, allowed, emptied)
+		if excluded != sub && subs == nil {
+			subs = append([]*syntax.Regexp(nil), regexp.Sub...)
+		}
+		if subs != nil {
+			subs[i] = excluded
+		}
+	}
+	if subs == nil {
+		return regexp
+	}
+	copied := *regexp
+	copied.Sub = subs
+	return &copied
+}
+
+// excludeLiteralRunes is excludeRunes for a literal.
+func excludeLiteralRunes(regexp *syntax.Regexp, allowed *tCharClass, emptied map[*syntax.Regexp]*syntax.Regexp) *syntax.Regexp {
+	classes := make([]*tCharClass, len(regexp.Rune))
+	changed := false
+	for i, r := range regexp.Rune {
+		variants := []rune{r}
+		if regexp.Flags&syntax.FoldCase != 0 {
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				variants = append(variants, f)
+			}
+		}
+		classes[i] = runeSetClass(variants).intersectClass(allowed)
+		if classes[i] == nil || classes[i].TotalSize != int32(len(variants)) {
+			changed = true
+		}
+	}
+	if !changed {
+		return regexp
+	}
+
+	concat := &syntax.Regexp{Op: syntax.OpConcat, Flags: regexp.Flags}
+	for _, class := range classes {
+		if class != nil && class.TotalSize == 1 {
+			concat.Sub = append(concat.Sub, &syntax.Regexp{
+				Op: syntax.OpLiteral, Flags: regexp.Flags &^ syntax.FoldCase, Rune: []rune{class.Ranges[0].Start},
+			})
+		} else {
+			concat.Sub = append(concat.Sub, restrictedClass(regexp, class, emptied))
+		}
+	}
+	if len(concat.Sub) == 1 {
+		return concat.Sub[0]
+	}
+	return concat
+}
+
+// restrictedClass returns a class node for the runes of class, standing in for regexp, or an empty one recorded
+// in emptied if class is nil.
+func restrictedClass(regexp *syntax.Regexp, class *tCharClass, emptied map[*syntax.Regexp]*syntax.Regexp) *syntax.Regexp {
+	node := &syntax.Regexp{Op: syntax.OpCharClass, Flags: regexp.Flags &^ syntax.FoldCase, Rune: []rune{}}
+	if class == nil {
+		emptied[node] = regexp
+	} else {
+		node.Rune = class.runeRanges()
+	}
+	return node
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestExcludeRunes(t *testing.T) {
+	t.Parallel()
+
+	generate := func(t *testing.T, pattern string, exclude string) []string {
+		gen, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, ExcludeRunes: []rune(exclude)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return gen.GenerateN(100)
+	}
+
+	for pattern, exclude := range map[string]string{
+		`[a-z],?`:      ",",
+		`.{20}`:        ",\"\n",
+		`[^a]{10}`:     ",\"",
+		`(a|,|b)+`:     ",",
+		`(?i)[a-c]{5}`: "bB",
+		`(?i)ab`:       "A",
+		`(?s:.)*x`:     "\n",
+	} {
+		pattern, exclude := pattern, exclude
+		t.Run(pattern, func(t *testing.T) {
+			t.Parallel()
+			for _, s := range generate(t, pattern, exclude) {
+				if strings.ContainsAny(s, exclude) {
+					t.Fatalf("generated %q, with one of %q", s, exclude)
+				}
+			}
+		})
+	}
+
+	t.Run("Case variants", func(t *testing.T) {
+		t.Parallel()
+		for _, s := range generate(t, `(?i)ab`, "A") {
+			if s[0] != 'a' {
+				t.Fatalf("should only generate lowercase a, generated %q", s)
+			}
+		}
+	})
+
+	t.Run("Names emptied class", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewGenerator(`a[,;]b`, &GeneratorArgs{ExcludeRunes: []rune(",;")})
+		if err == nil || !strings.Contains(err.Error(), "[,;]") {
+			t.Fatalf("should name the class, was %v", err)
+		}
+	})
+
+	t.Run("Excluded literal", func(t *testing.T) {
+		t.Parallel()
+		if _, err := NewGenerator(`a,b`, &GeneratorArgs{ExcludeRunes: []rune(",")}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+
+	t.Run("Invalid rune", func(t *testing.T) {
+		t.Parallel()
+		if _, err := NewGenerator(`a`, &GeneratorArgs{ExcludeRunes: []rune{0}}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+/*
+GenerateShortest returns the shortest string the generator generates, by walking its expression instead of
+generating at random: repetitions are generated the minimum number of times, optional expressions are dropped,
+and the first of the shortest alternation branches is taken. Character classes generate their lowest graphic rune
+other than space, or their lowest rune if they have none, and pooled groups their first shortest value.
+
+Assertions are not checked, and CaptureGroupHandlers aren't called. Scheduled generators return the first shortest
+string of the generators they delegate to.
+*/
+func (gen *internalGenerator) GenerateShortest() string {
+	return gen.generateExtreme(false)
+}
+
+// GenerateLongest is like GenerateShortest, but returns the longest string: repetitions are generated the maximum
+// number of times, MaxUnboundedRepeatCount or StarMax, PlusMax or OpenRangeMax for unbounded ones, and the first of
+// the longest alternation branches and pooled values is taken.
+func (gen *internalGenerator) GenerateLongest() string {
+	return gen.generateExtreme(true)
+}
+
+func (gen *internalGenerator) generateExtreme(longest bool) string {
+	if gen.regexp == nil {
+		return scheduledExtreme(gen.scheduled, longest)
+	}
+	var result strings.Builder
+	writeExtreme(&result, gen.regexp, gen.args, map[int]string{}, longest)
+	return result.String()
+}
+
+// writeExtreme writes the shortest or longest string generated from regexp to out, recording the values of capture
+// groups by 0-based index in groups for backreferences.
+func writeExtreme(out *strings.Builder, regexp *syntax.Regexp, args *GeneratorArgs, groups map[int]string, longest bool) {
+	// better reports whether a length is preferred to the best one so far.
+	better := func(length, best int) bool {
+		if longest {
+			return length > best
+		}
+		return length < best
+	}
+
+	switch regexp.Op {
+	case syntax.OpLiteral:
+		for _, r := range regexp.Rune {
+			if index, ok := backreferenceIndex(r); ok {
+				out.WriteString(groups[index])
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		if class := generatedClass(regexp, args); class != nil {
+			out.WriteRune(lowestRune(class.runeRanges()))
+		}
+	case syntax.OpCapture:
+		value, pooled := "", false
+		if pool, ok := args.GroupValuePools[regexp.Name]; ok && regexp.Name != "" {
+			for _, v := range pool {
+				if !pooled || better(len([]rune(v)), len([]rune(value))) {
+					value, pooled = v, true
+				}
+			}
+		}
+		if !pooled {
+			var sub strings.Builder
+			writeExtreme(&sub, regexp.Sub[0], args, groups, longest)
+			value = sub.String()
+		}
+		groups[regexp.Cap-1] = value
+		out.WriteString(value)
+	case syntax.OpConcat:
+		for _, sub := range regexp.Sub {
+			writeExtreme(out, sub, args, groups, longest)
+		}
+	case syntax.OpAlternate:
+		var best *syntax.Regexp
+		bestLength := 0
+		for _, sub := range regexp.Sub {
+			if unmatchable(sub) != nil {
+				continue
+			}
+			length, max := lengthBounds(sub, args)
+			if longest {
+				length = max
+			}
+			if best == nil || better(length, bestLength) {
+				best, bestLength = sub, length
+			}
+		}
+		if best != nil {
+			writeExtreme(out, best, args, groups, longest)
+		}
+	case syntax.OpQuest, syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		count, max := repeatBounds(regexp, args)
+		if longest {
+			count = max
+		}
+		for i := 0; i < count; i++ {
+			writeExtreme(out, regexp.Sub[0], args, groups, longest)
+		}
+	}
+	// Empty matches and assertions generate nothing.
+}
+
+// lowestRune returns the lowest graphic rune other than space in the ranges of a character class, or the lowest
+// rune if there is none.
+func lowestRune(ranges []rune) rune {
+	for i := 0; i < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1]; r++ {
+			if unicode.IsGraphic(r) && r != ' ' {
+				return r
+			}
+			if r == unicode.MaxRune {
+				break
+			}
+		}
+	}
+	return ranges[0]
+}
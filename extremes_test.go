@@ -0,0 +1,80 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestGenerateShortest(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern  string
+		args     *GeneratorArgs
+		expected string
+	}{
+		{`a{3,7}`, nil, "aaa"},
+		{`(foo|barbar)`, nil, "foo"},
+		{`(barbar|foo|baz)`, nil, "foo"},
+		{`a*`, nil, ""},
+		{`a+b?c{2,}`, nil, "acc"},
+		{`x*`, &GeneratorArgs{MinUnboundedRepeatCount: 2}, "xx"},
+		{`[b-z][0-9]\.`, nil, "b0."},
+		{`.[\x00-\x20]`, nil, "!\x01"},
+		{`^\bab$`, &GeneratorArgs{Flags: syntax.Perl}, "ab"},
+		{`(a|bc)-\1`, &GeneratorArgs{Flags: syntax.Perl}, "a-a"},
+		{`(?P<x>\d+)`, &GeneratorArgs{Flags: syntax.Perl, GroupValuePools: map[string][]string{"x": {"123", "45", "67"}}}, "45"},
+	} {
+		generator, err := NewGenerator(tc.pattern, tc.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := generator.GenerateShortest(); s != tc.expected {
+			t.Errorf("/%s/ should generate %q, was %q", tc.pattern, tc.expected, s)
+		}
+	}
+}
+
+func TestGenerateLongest(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern  string
+		args     *GeneratorArgs
+		expected string
+	}{
+		{`a{3,7}`, nil, "aaaaaaa"},
+		{`(foo|barbar)`, nil, "barbar"},
+		{`(foo|barbar|bazbaz)`, nil, "barbar"},
+		{`a*`, &GeneratorArgs{MaxUnboundedRepeatCount: 4}, "aaaa"},
+		{`a+b?c{2,}`, &GeneratorArgs{MaxUnboundedRepeatCount: 3}, "aaabccc"},
+		{`x*`, &GeneratorArgs{MaxTotalLength: 5}, "xxxxx"},
+		{`(a|bc)-\1`, &GeneratorArgs{Flags: syntax.Perl}, "bc-bc"},
+		{`(?P<x>\d+)`, &GeneratorArgs{Flags: syntax.Perl, GroupValuePools: map[string][]string{"x": {"12", "345", "678"}}}, "345"},
+	} {
+		generator, err := NewGenerator(tc.pattern, tc.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := generator.GenerateLongest(); s != tc.expected {
+			t.Errorf("/%s/ should generate %q, was %q", tc.pattern, tc.expected, s)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"time"
+)
+
+// RetryPolicy controls how filtering methods such as GenerateSatisfying retry when a generated string is rejected.
+type RetryPolicy struct {
+	// Maximum number of strings to generate before giving up. Must be at least 1.
+	MaxAttempts int
+
+	// Backoff returns how long to wait after the given failed attempt (starting at 1) before generating again,
+	// e.g. when the filter queries a remote service. If nil, attempts are not delayed.
+	Backoff func(attempt int) time.Duration
+}
+
+// retry calls try until it returns true or MaxAttempts is reached. It returns the number of attempts made.
+func (policy RetryPolicy) retry(try func() bool) (attempts int, err error) {
+	if policy.MaxAttempts < 1 {
+		return 0, generatorError(nil, "RetryPolicy.MaxAttempts must be at least 1, was %d", policy.MaxAttempts)
+	}
+
+	for attempts = 1; attempts <= policy.MaxAttempts; attempts++ {
+		if try() {
+			return attempts, nil
+		}
+		if policy.Backoff != nil && attempts < policy.MaxAttempts {
+			time.Sleep(policy.Backoff(attempts))
+		}
+	}
+	return policy.MaxAttempts, generatorError(nil, "no acceptable string generated in %d attempts", policy.MaxAttempts)
+}
+
+// GenerateSatisfying generates strings until one satisfies predicate, retrying according to policy.
+func (gen *internalGenerator) GenerateSatisfying(predicate func(string) bool, policy RetryPolicy) (string, error) {
+	result, _, err := gen.GenerateSatisfyingWithToken(predicate, policy)
+	return result, err
+}
+
+// GenerateSatisfyingWithToken is like GenerateSatisfying, but also returns a token GenerateFromToken reproduces
+// the result from.
+func (gen *internalGenerator) GenerateSatisfyingWithToken(predicate func(string) bool, policy RetryPolicy) (
+	value, token string, err error) {
+	seed := gen.newState().rng.Uint64()
+	state := newSeededState(seed)
+
+	var attempts int
+	attempts, err = policy.retry(func() bool {
+		value = gen.generateString(state)
+		return predicate(value)
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if attempts > MaxTokenAttempts {
+		return "", "", generatorError(nil, "%q took %d attempts, more than a token can reproduce", value, attempts)
+	}
+	return value, formatToken(seed, attempts), nil
+}
+
+// GenerateExcluding generates strings until one is not in excluded, retrying according to policy.
+func (gen *internalGenerator) GenerateExcluding(excluded []string, policy RetryPolicy) (string, error) {
+	set := make(map[string]struct{}, len(excluded))
+	for _, s := range excluded {
+		set[s] = struct{}{}
+	}
+
+	return gen.GenerateSatisfying(func(s string) bool {
+		_, ok := set[s]
+		return !ok
+	}, policy)
+}
+
+// Number of strings GenerateUnique generates per string asked for, unless GeneratorArgs.MaxUniqueAttempts is set.
+const uniqueAttemptsPerString = 100
+
+/*
+GenerateUnique generates n distinct strings, in the order they were first generated.
+
+It generates at most GeneratorArgs.MaxUniqueAttempts strings (100 times n by default), so it terminates even for
+expressions that match fewer than n strings. If it runs out of attempts, it returns the distinct strings found so far
+with an error.
+*/
+func (gen *internalGenerator) GenerateUnique(n int) ([]string, error) {
+	if n < 0 {
+		return nil, generatorError(nil, "n must not be negative, was %d", n)
+	}
+
+	maxAttempts := n * uniqueAttemptsPerString
+	if gen.args != nil && gen.args.MaxUniqueAttempts > 0 {
+		maxAttempts = gen.args.MaxUniqueAttempts
+	}
+
+	results := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	state := gen.newState()
+	for attempts := 0; len(results) < n; attempts++ {
+		if attempts == maxAttempts {
+			return results, generatorError(nil, "/%s/: found %d of %d unique strings in %d attempts",
+				gen, len(results), n, maxAttempts)
+		}
+		s := gen.generateString(state)
+		if _, ok := seen[s]; !ok {
+			seen[s] = struct{}{}
+			results = append(results, s)
+		}
+	}
+	return results, nil
+}
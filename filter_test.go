@@ -0,0 +1,151 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateSatisfying(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Returns a satisfying string", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("[ab]{3}", nil)
+		s, err := generator.GenerateSatisfying(func(s string) bool {
+			return strings.HasPrefix(s, "b")
+		}, RetryPolicy{MaxAttempts: 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(s, "b") {
+			t.Fatalf("%q should start with b", s)
+		}
+	})
+
+	t.Run("Gives up after MaxAttempts with backoff", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("a", nil)
+		var calls, backoffs []int
+		_, err := generator.GenerateSatisfying(func(s string) bool {
+			calls = append(calls, len(calls)+1)
+			return false
+		}, RetryPolicy{
+			MaxAttempts: 3,
+			Backoff: func(attempt int) time.Duration {
+				backoffs = append(backoffs, attempt)
+				return time.Millisecond
+			},
+		})
+		if err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if len(calls) != 3 {
+			t.Fatalf("should make 3 attempts, made %d", len(calls))
+		}
+		if len(backoffs) != 2 || backoffs[0] != 1 || backoffs[1] != 2 {
+			t.Fatalf("should back off after attempts 1 and 2, got %v", backoffs)
+		}
+	})
+
+	t.Run("Rejects invalid MaxAttempts", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("a", nil)
+		if _, err := generator.GenerateSatisfying(func(string) bool { return true }, RetryPolicy{}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+func TestGenerateExcluding(t *testing.T) {
+	t.Parallel()
+
+	generator, _ := NewGenerator("[abc]", nil)
+	for i := 0; i < 100; i++ {
+		s, err := generator.GenerateExcluding([]string{"a", "b"}, RetryPolicy{MaxAttempts: 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != "c" {
+			t.Fatalf("should be c, was %q", s)
+		}
+	}
+
+	if _, err := generator.GenerateExcluding([]string{"a", "b", "c"}, RetryPolicy{MaxAttempts: 10}); err == nil {
+		t.Fatal("err should not be nil")
+	}
+}
+
+func TestGenerateUnique(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Generates distinct strings", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("[a-z]{3}", nil)
+		results, err := generator.GenerateUnique(500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 500 {
+			t.Fatal("should be equal:", len(results), 500)
+		}
+		seen := map[string]bool{}
+		for _, s := range results {
+			if seen[s] {
+				t.Fatalf("%q generated twice", s)
+			}
+			seen[s] = true
+		}
+	})
+
+	t.Run("Returns the strings found when there are too few", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("[ab]", nil)
+		results, err := generator.GenerateUnique(3)
+		if err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if len(results) != 2 {
+			t.Fatalf("should find a and b, found %q", results)
+		}
+	})
+
+	t.Run("MaxUniqueAttempts", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("[a-z]{8}", &GeneratorArgs{MaxUniqueAttempts: 5})
+		results, err := generator.GenerateUnique(10)
+		if err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if len(results) > 5 {
+			t.Fatal("should make at most 5 attempts, found", len(results))
+		}
+
+		if _, err := NewGenerator("a", &GeneratorArgs{MaxUniqueAttempts: -1}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"unicode"
+)
+
+// caseVariants returns r and the other runes it matches case-insensitively, e.g. k, K and the Kelvin sign K for k.
+func caseVariants(r rune) []rune {
+	variants := []rune{r}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		variants = append(variants, f)
+	}
+	return variants
+}
+
+// createFoldCaseGenerator returns a generator for a case-insensitive literal that picks one of the case variants of
+// each of its runes at random, e.g. "CaT" for `(?i)cat`, or nil if the literal isn't case-insensitive.
+func createFoldCaseGenerator(regexp *syntax.Regexp) *internalGenerator {
+	if regexp.Flags&syntax.FoldCase == 0 {
+		return nil
+	}
+
+	variants := make([][]rune, len(regexp.Rune))
+	for i, r := range regexp.Rune {
+		variants[i] = caseVariants(r)
+	}
+
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+		for _, runeVariants := range variants {
+			if len(runeVariants) == 1 {
+				state.out.WriteRune(runeVariants[0])
+				continue
+			}
+			state.out.WriteRune(runeVariants[state.rng.Intn(len(runeVariants))])
+		}
+	}}
+}
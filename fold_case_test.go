@@ -0,0 +1,75 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"testing"
+)
+
+func TestFoldCase(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Varies case", func(t *testing.T) {
+		t.Parallel()
+
+		for _, args := range []struct {
+			pattern string
+			flags   syntax.Flags
+		}{
+			{`(?i)cat`, syntax.Perl},
+			{`cat`, syntax.FoldCase},
+		} {
+			generator, err := NewGenerator(args.pattern, &GeneratorArgs{Flags: args.flags})
+			if err != nil {
+				t.Fatal(err)
+			}
+			histogram := map[string]int{}
+			for i := 0; i < SampleSize; i++ {
+				histogram[generator.Generate()]++
+			}
+			for s := range histogram {
+				if matched, _ := regexp.MatchString(`(?i)^cat$`, s); !matched {
+					t.Fatalf("%q does not match", s)
+				}
+			}
+			if len(histogram) < 2 {
+				t.Fatalf("should generate several case variants, generated %v", histogram)
+			}
+		}
+	})
+
+	t.Run("Only varies case-insensitive parts", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{Flags: syntax.Perl}, `a(?i:b)c`, `^a[bB]c$`)
+	})
+
+	t.Run("Alphabet and NumMatches", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`(?i)ab1`, &GeneratorArgs{Flags: syntax.Perl})
+		if alphabet := string(generator.Alphabet()); alphabet != "1ABab" {
+			t.Fatal("should be equal:", alphabet, "1ABab")
+		}
+		if n := generator.NumMatches().Int64(); n != 4 {
+			t.Fatal("should be equal:", n, 4)
+		}
+	})
+}
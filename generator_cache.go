@@ -0,0 +1,116 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+/*
+GeneratorCache holds generators for recently used patterns, evicting the least recently used ones once it holds
+more than its maximum number of entries.
+
+It is intended for services that build generators from patterns supplied at runtime, where caching every
+pattern ever seen would grow without bound. A GeneratorCache is safe for concurrent use.
+
+Entries are keyed by pattern and by the *GeneratorArgs pointer, not by the args' values, so callers must pass the
+same pointer (or nil) to share a generator: building a new &GeneratorArgs{...} for every Get never hits the cache.
+*/
+type GeneratorCache struct {
+	maxEntries int
+
+	mutex   sync.Mutex
+	entries map[generatorCacheKey]*list.Element
+	// Most recently used at the front.
+	lru *list.List
+}
+
+// Args are compared by pointer, since they contain maps and functions.
+type generatorCacheKey struct {
+	pattern string
+	args    *GeneratorArgs
+}
+
+type generatorCacheEntry struct {
+	key       generatorCacheKey
+	generator Generator
+}
+
+// NewGeneratorCache creates a cache holding at most maxEntries generators. It panics if maxEntries isn't positive.
+func NewGeneratorCache(maxEntries int) *GeneratorCache {
+	if maxEntries <= 0 {
+		panic(fmt.Sprintf("NewGeneratorCache: maxEntries must be positive, was %d", maxEntries))
+	}
+	return &GeneratorCache{
+		maxEntries: maxEntries,
+		entries:    map[generatorCacheKey]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+/*
+Get returns the cached generator for pattern and args, creating it with NewGenerator on a miss.
+Errors from NewGenerator are returned, and not cached.
+
+Args are compared by pointer: passing the same *GeneratorArgs again hits the cache, but an equal copy doesn't.
+Since the args are copied when the generator is created, changing them after the first Get won't affect the
+cached generator.
+*/
+func (cache *GeneratorCache) Get(pattern string, args *GeneratorArgs) (Generator, error) {
+	key := generatorCacheKey{pattern, args}
+
+	cache.mutex.Lock()
+	if element, ok := cache.entries[key]; ok {
+		cache.lru.MoveToFront(element)
+		cache.mutex.Unlock()
+		return element.Value.(*generatorCacheEntry).generator, nil
+	}
+	cache.mutex.Unlock()
+
+	// Don't hold the lock while building, so slow patterns don't block hits on other ones.
+	generator, err := NewGenerator(pattern, args)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	// Another goroutine may have built it in the meantime; keep the first one so all callers share it.
+	if element, ok := cache.entries[key]; ok {
+		cache.lru.MoveToFront(element)
+		return element.Value.(*generatorCacheEntry).generator, nil
+	}
+
+	cache.entries[key] = cache.lru.PushFront(&generatorCacheEntry{key, generator})
+	for cache.lru.Len() > cache.maxEntries {
+		oldest := cache.lru.Back()
+		cache.lru.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*generatorCacheEntry).key)
+	}
+	return generator, nil
+}
+
+// Len returns the number of generators currently cached.
+func (cache *GeneratorCache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.lru.Len()
+}
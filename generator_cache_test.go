@@ -0,0 +1,130 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestGeneratorCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Hits return the same generator", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGeneratorCache(2)
+		args := &GeneratorArgs{}
+		first, err := cache.Get("a+", args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := cache.Get("a+", args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first != second {
+			t.Fatal("should be the same generator")
+		}
+
+		other, _ := cache.Get("a+", &GeneratorArgs{})
+		if other == first {
+			t.Fatal("different args should be a different generator")
+		}
+	})
+
+	t.Run("Evicts least recently used", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGeneratorCache(2)
+		a, _ := cache.Get("a", nil)
+		b, _ := cache.Get("b", nil)
+		// Use a, so b is the least recently used.
+		cache.Get("a", nil)
+		cache.Get("c", nil)
+
+		if cache.Len() != 2 {
+			t.Fatalf("should hold 2 generators, held %d", cache.Len())
+		}
+		if again, _ := cache.Get("a", nil); again != a {
+			t.Fatal("a should still be cached")
+		}
+		if again, _ := cache.Get("b", nil); again == b {
+			t.Fatal("b should have been evicted")
+		}
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGeneratorCache(2)
+		if _, err := cache.Get("a(", nil); err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if cache.Len() != 0 {
+			t.Fatal("should be empty")
+		}
+	})
+
+	t.Run("Panics on non-positive sizes", func(t *testing.T) {
+		t.Parallel()
+
+		for _, size := range []int{0, -1} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Fatalf("size %d should panic", size)
+					}
+				}()
+				NewGeneratorCache(size)
+			}()
+		}
+	})
+
+	t.Run("Concurrent access", func(t *testing.T) {
+		t.Parallel()
+
+		cache := NewGeneratorCache(4)
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					pattern := fmt.Sprintf("x{%d}", (i+j)%8)
+					generator, err := cache.Get(pattern, nil)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if matched, _ := regexp.MatchString("^"+pattern+"$", generator.Generate()); !matched {
+						t.Error("should match")
+						return
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if cache.Len() > 4 {
+			t.Fatalf("should hold at most 4 generators, held %d", cache.Len())
+		}
+	})
+}
@@ -18,9 +18,33 @@ limitations under the License.
 package regen
 
 import (
+	"errors"
 	"fmt"
 )
 
+// ErrCaptureNestingTooDeep is returned by NewGenerator when capture groups are nested deeper than
+// GeneratorArgs.MaxCaptureNesting.
+var ErrCaptureNestingTooDeep = errors.New("capture groups nested too deeply")
+
+// ErrExpressionTooDeep is returned by NewGenerator when the syntax tree of the expression is deeper than
+// GeneratorArgs.MaxDepth.
+var ErrExpressionTooDeep = errors.New("expression nested too deeply")
+
+// ErrInvalidOutput is returned by GenerateE when GeneratorArgs.ValidateOutput is set and the string generated
+// doesn't match the expression.
+var ErrInvalidOutput = errors.New("generated string doesn't match expression")
+
+// ErrOutputTooLarge is returned by GenerateE when generation was aborted for exceeding GeneratorArgs.MaxOutputSize.
+var ErrOutputTooLarge = errors.New("generated output too large")
+
+// ErrTooManyAlternationBranches is returned by NewGenerator when an alternation has more branches than
+// GeneratorArgs.MaxAlternationBranches.
+var ErrTooManyAlternationBranches = errors.New("too many alternation branches")
+
+// ErrUnsatisfiable is returned by NewGenerator when no string can match the expression, e.g. because
+// of content after an end-of-text anchor.
+var ErrUnsatisfiable = errors.New("expression can never match")
+
 // Error returned by a generatorFactory if the AST is invalid.
 type tGeneratorError struct {
 	ErrorStr string
@@ -37,3 +61,8 @@ func (err *tGeneratorError) Error() string {
 	}
 	return err.ErrorStr
 }
+
+// Unwrap returns the cause of the error, so that errors.Is and errors.As see through it.
+func (err *tGeneratorError) Unwrap() error {
+	return err.Cause
+}
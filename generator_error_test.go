@@ -35,3 +35,11 @@ func TestFormats(t *testing.T) {
 		t.Fatalf("wrong message: %+v", err)
 	}
 }
+
+func TestUnwrap(t *testing.T) {
+	cause := errors.New("cause")
+	err := generatorError(cause, "msg")
+	if !errors.Is(err, cause) {
+		t.Fatalf("should wrap cause: %+v", err)
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"sync/atomic"
+)
+
+// createGroupPoolGenerator returns a generator for a capture group that emits values from pool, in order or
+// at random depending on args.RandomGroupValuePools.
+func createGroupPoolGenerator(regexp *syntax.Regexp, pool []string, args *GeneratorArgs) *internalGenerator {
+	// Copy the pool so the caller can't change it.
+	pool = append([]string(nil), pool...)
+	index := regexp.Cap - 1
+
+	if args.RandomGroupValuePools && !args.Deterministic {
+		return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+			state.writeCapture(index, regexp.Name, pool[state.rng.Intn(len(pool))], len(state.spans))
+		}}
+	}
+
+	var next uint64
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+		i := atomic.AddUint64(&next, 1) - 1
+		state.writeCapture(index, regexp.Name, pool[i%uint64(len(pool))], len(state.spans))
+	}}
+}
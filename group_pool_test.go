@@ -0,0 +1,106 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestGroupValuePools(t *testing.T) {
+	t.Parallel()
+
+	regions := []string{"eu-west", "us-east", "ap-south"}
+
+	t.Run("Cycles through the pool", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(?P<region>[a-z]+)/(?P<id>\d{3})`, &GeneratorArgs{
+			Flags:           syntax.Perl,
+			GroupValuePools: map[string][]string{"region": regions},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 2*len(regions); i++ {
+			s := generator.Generate()
+			expected := regions[i%len(regions)] + "/"
+			if s[:len(expected)] != expected {
+				t.Fatalf("%q should start with %q", s, expected)
+			}
+		}
+	})
+
+	t.Run("Draws at random", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(?P<region>[a-z]+)`, &GeneratorArgs{
+			Flags:                 syntax.Perl,
+			GroupValuePools:       map[string][]string{"region": regions},
+			RandomGroupValuePools: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		seen := map[string]int{}
+		for i := 0; i < SampleSize; i++ {
+			seen[generator.Generate()]++
+		}
+		if len(seen) != len(regions) {
+			t.Fatalf("should draw every region, drew %v", seen)
+		}
+	})
+
+	t.Run("Rejects empty pools", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewGenerator(`(?P<region>[a-z]+)`, &GeneratorArgs{
+			Flags:           syntax.Perl,
+			GroupValuePools: map[string][]string{"region": nil},
+		})
+		if err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+func TestFixedGroups(t *testing.T) {
+	t.Parallel()
+
+	generator, err := NewGenerator(`(?P<user>[a-z]{3,8})/(?P<id>\d+)/(?P=id)`, &GeneratorArgs{
+		Flags:       syntax.Perl | syntax.PerlX,
+		FixedGroups: map[string]string{"id": "not-a-number", "other": "x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := map[string]bool{}
+	for i := 0; i < SampleSize; i++ {
+		s, named, _ := generator.GenerateWithCaptures()
+		if s != named["user"]+"/not-a-number/not-a-number" {
+			t.Fatalf("%q should have the fixed id, with the user generated", s)
+		}
+		users[named["user"]] = true
+	}
+	if len(users) < 2 {
+		t.Fatalf("user should still be generated at random, was only %v", users)
+	}
+}
@@ -0,0 +1,32 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"hash"
+	"io"
+)
+
+// GenerateWithHash generates a string, and returns it with the sum h computes over its bytes, e.g. to fingerprint
+// generated fixtures. h is reset first, so the sum only covers the returned string.
+func (gen *internalGenerator) GenerateWithHash(h hash.Hash) (string, []byte) {
+	s := gen.Generate()
+	h.Reset()
+	io.WriteString(h, s)
+	return s, h.Sum(nil)
+}
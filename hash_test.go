@@ -0,0 +1,45 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"bytes"
+	"hash/fnv"
+	"testing"
+)
+
+func TestGenerateWithHash(t *testing.T) {
+	t.Parallel()
+
+	generator, err := NewGenerator(`[a-z]{1,16}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reused across calls, so stale state would show up.
+	h := fnv.New64a()
+	for i := 0; i < SampleSize; i++ {
+		s, sum := generator.GenerateWithHash(h)
+
+		expected := fnv.New64a()
+		expected.Write([]byte(s))
+		if !bytes.Equal(sum, expected.Sum(nil)) {
+			t.Fatalf("hash of %q should be equal", s)
+		}
+	}
+}
@@ -19,9 +19,13 @@ package regen
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"math"
+	"math/rand"
+	"regexp"
 	"regexp/syntax"
+	"strings"
+	"sync"
 )
 
 // generatorFactory is a function that creates a random string generator from a regular expression AST.
@@ -57,17 +61,270 @@ func init() {
 
 type internalGenerator struct {
 	Name         string
-	GenerateFunc func() string
+	GenerateFunc func(state *generatorState)
+
+	// The expression this generator was created from, before simplification, and the arguments it was created with.
+	regexp *syntax.Regexp
+	args   *GeneratorArgs
+
+	// The generators a scheduled generator, which has no expression, delegates to.
+	scheduled []Generator
+
+	compileOnce sync.Once
+	compiled    *regexp.Regexp
+	compileErr  error
+
+	fullOnce sync.Once
+	full     *regexp.Regexp
+	fullErr  error
+}
+
+// generatorState is what a single call to Generate draws on, passed down to every node of the tree.
+type generatorState struct {
+	rng *rand.Rand
+
+	// Where generated strings are written.
+	out output
+
+	// Closed when generation is cancelled, or nil if it can't be.
+	done <-chan struct{}
+
+	// Values generated for each capture group, by 0-based index, if recording.
+	captures map[int][]string
+
+	// Where the values of capture groups were written, in the order they were, if recording.
+	spans []capturedSpan
+
+	// The last value generated for each capture group, by 0-based index, for expressions with backreferences.
+	groups map[int]string
+
+	// The first error generation ran into. Generate ignores it, and GenerateE returns it.
+	err error
+
+	// The maximum number of bytes output may hold at once, or 0 if unlimited, the number it holds, and whether
+	// generation was aborted for exceeding it.
+	maxSize int
+	size    int
+	aborted bool
+}
+
+// recordCapture records value as generated for the capture group at index, if recording.
+func (state *generatorState) recordCapture(index int, value string) string {
+	if state.captures != nil {
+		state.captures[index] = append(state.captures[index], value)
+	}
+	if state.groups != nil {
+		state.groups[index] = value
+	}
+	return value
+}
+
+/*
+writeCapture writes value as generated for the capture group at index, recording it. nested is the number of spans
+recorded before value was generated, so that the spans of groups nested in it, recorded relative to value, are
+moved to where it is written.
+*/
+func (state *generatorState) writeCapture(index int, name string, value string, nested int) {
+	if state.spans != nil {
+		start := outputLen(state.out)
+		if start < 0 {
+			// Where value is written isn't known, so neither are the spans nested in it.
+			state.spans = state.spans[:nested]
+			state.out.WriteString(state.recordCapture(index, value))
+			return
+		}
+		for i := nested; i < len(state.spans); i++ {
+			state.spans[i].Start += start
+			state.spans[i].End += start
+		}
+		state.spans = append(state.spans, capturedSpan{
+			CaptureSpan: CaptureSpan{Index: index, Name: name, Start: start, End: start + len(value)},
+			value:       value,
+		})
+	}
+	state.out.WriteString(state.recordCapture(index, value))
+}
+
+// resetCaptures forgets the capture groups recorded, for when the string they were generated for is discarded.
+func (state *generatorState) resetCaptures() {
+	if state.captures != nil {
+		state.captures = map[int][]string{}
+	}
+	if state.spans != nil {
+		state.spans = state.spans[:0]
+	}
+}
+
+// fail records err as the reason the generated string is unusable, unless there already is one.
+func (state *generatorState) fail(err error) {
+	if state.err == nil {
+		state.err = err
+	}
+}
+
+// abort records err, and stops generating the string.
+func (state *generatorState) abort(err error) {
+	state.fail(err)
+	state.aborted = true
+}
+
+// cancelled reports whether generation was cancelled or aborted, in which case generators should return early.
+func (state *generatorState) cancelled() bool {
+	if state.aborted {
+		return true
+	}
+	select {
+	case <-state.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// output is what generators write to: a strings.Builder, or a bufio.Writer in WriteTo.
+type output interface {
+	WriteString(s string) (int, error)
+	WriteRune(r rune) (int, error)
+}
+
+func (gen *internalGenerator) newState() *generatorState {
+	// Generators not created by NewGenerator, e.g. scheduled ones, have no args.
+	if gen.args == nil {
+		return &generatorState{rng: defaultRng}
+	}
+	return &generatorState{rng: gen.args.rng}
 }
 
 func (gen *internalGenerator) Generate() string {
-	return gen.GenerateFunc()
+	// The background context is never cancelled.
+	s, _ := gen.GenerateContext(context.Background())
+	return s
+}
+
+// GenerateContext is like Generate, but returns early with ctx.Err() if ctx is cancelled while generating.
+// Repetitions and concatenations check ctx before generating each part, so even a single huge repetition stops
+// promptly.
+func (gen *internalGenerator) GenerateContext(ctx context.Context) (string, error) {
+	state := gen.newState()
+	state.done = ctx.Done()
+	s := gen.generateString(state)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// GenerateE is like Generate, but returns the error, if any, that made the string generated unusable.
+func (gen *internalGenerator) GenerateE() (string, error) {
+	state := gen.newState()
+	s := gen.generateString(state)
+	if state.err != nil {
+		return "", state.err
+	}
+	return s, nil
+}
+
+// GenerateBytes generates a string into a new byte slice, without copying it from a string.
+func (gen *internalGenerator) GenerateBytes() []byte {
+	state := gen.newState()
+	var result bytes.Buffer
+	state.out = &result
+	gen.GenerateFunc(state)
+	return result.Bytes()
+}
+
+// generateString generates with state, returning the result instead of writing it to state.out.
+func (gen *internalGenerator) generateString(state *generatorState) string {
+	out := state.out
+	defer func() { state.out = out }()
+
+	var result strings.Builder
+	state.out = state.limitSize(&result)
+	gen.GenerateFunc(state)
+	// What's returned isn't held here anymore, and is counted again wherever it is written.
+	if state.maxSize > 0 {
+		state.size -= result.Len()
+	}
+	return result.String()
+}
+
+// GenerateN generates n strings with a single state, and returns them in a slice allocated once.
+// It panics if n is negative.
+func (gen *internalGenerator) GenerateN(n int) []string {
+	results := make([]string, n)
+	state := gen.newState()
+	generate := func() string { return gen.generateString(state) }
+	for i := range results {
+		var previous *string
+		if i > 0 {
+			previous = &results[i-1]
+		}
+		results[i] = gen.nextInStream(previous, generate)
+	}
+	return results
+}
+
+// bind returns a copy of gen that always generates with state, to hand to code that calls Generate,
+// like CaptureGroupHandlers.
+func (gen *internalGenerator) bind(state *generatorState) *internalGenerator {
+	return &internalGenerator{Name: gen.Name, regexp: gen.regexp, args: gen.args, GenerateFunc: func(s *generatorState) {
+		s.out.WriteString(gen.generateString(state))
+	}}
+}
+
+/*
+Clone returns a generator for the same expression and args, without parsing it again. If the generator has an
+RngSource (other than CryptoRngSource), the clone gets its own source, seeded with the next number from the
+generator's, so clones of a seeded generator are reproducible but generate different strings from each other.
+*/
+func (gen *internalGenerator) Clone() Generator {
+	clone := &internalGenerator{Name: gen.Name, GenerateFunc: gen.GenerateFunc, regexp: gen.regexp, args: gen.args}
+	if gen.args != nil && gen.args.source != nil {
+		args := *gen.args
+		args.seed(gen.args.rng.Int63())
+		clone.args = &args
+	}
+	return clone
 }
 
 func (gen *internalGenerator) String() string {
 	return gen.Name
 }
 
+// CompiledRegexp returns the expression the generator was created from, compiled with the flags the generator
+// was created with. It is compiled on first use and cached.
+func (gen *internalGenerator) CompiledRegexp() (*regexp.Regexp, error) {
+	gen.compileOnce.Do(func() {
+		if gen.regexp == nil {
+			gen.compileErr = generatorError(nil, "generator /%s/ has no source expression", gen.Name)
+			return
+		}
+		if gen.args != nil && gen.args.backreferences {
+			gen.compileErr = generatorError(nil, "generator /%s/ has backreferences, which Go regexps don't support", gen.Name)
+			return
+		}
+		// syntax.Regexp.String() spells out the flags in effect, e.g. (?i:...) for FoldCase.
+		gen.compiled, gen.compileErr = regexp.Compile(gen.regexp.String())
+	})
+	return gen.compiled, gen.compileErr
+}
+
+// fullRegexp is like CompiledRegexp, but only matches whole strings.
+func (gen *internalGenerator) fullRegexp() (*regexp.Regexp, error) {
+	gen.fullOnce.Do(func() {
+		if gen.regexp == nil {
+			gen.fullErr = generatorError(nil, "generator /%s/ has no source expression", gen.Name)
+			return
+		}
+		if gen.args != nil && gen.args.backreferences {
+			gen.fullErr = generatorError(nil, "generator /%s/ has backreferences, which Go regexps don't support", gen.Name)
+			return
+		}
+		gen.full, gen.fullErr = regexp.Compile(`\A(?:` + gen.regexp.String() + `)\z`)
+	})
+	return gen.full, gen.fullErr
+}
+
 // Create a new generator for each expression in regexps.
 func newGenerators(regexps []*syntax.Regexp, args *GeneratorArgs) ([]*internalGenerator, error) {
 	generators := make([]*internalGenerator, len(regexps), len(regexps))
@@ -86,56 +343,125 @@ func newGenerators(regexps []*syntax.Regexp, args *GeneratorArgs) ([]*internalGe
 
 // Create a new generator for r.
 func newGenerator(regexp *syntax.Regexp, args *GeneratorArgs) (generator *internalGenerator, err error) {
-	simplified := regexp.Simplify()
-
-	factory, ok := generatorFactories[simplified.Op]
+	factory, ok := generatorFactories[regexp.Op]
 	if ok {
-		return factory(simplified, args)
+		generator, err = factory(regexp, args)
+		if err != nil {
+			return nil, err
+		}
+		if args.OnEmit != nil && emitsRunes(regexp.Op) {
+			generator = createEmitGenerator(generator, args.OnEmit)
+		}
+		generator.regexp = regexp
+		generator.args = args
+		return generator, nil
 	}
 
-	return nil, fmt.Errorf("invalid generator pattern: /%s/ as /%s/\n%s",
-		regexp, simplified, inspectRegexpToString(simplified))
+	return nil, fmt.Errorf("invalid generator pattern: /%s/\n%s", regexp, inspectRegexpToString(regexp))
+}
+
+// newRootGenerator wraps the generator for a whole expression with the processing applied to its final output.
+func newRootGenerator(generator *internalGenerator, args *GeneratorArgs) *internalGenerator {
+	generate := generator.GenerateFunc
+	if args.backreferences || args.CaptureGroupContextHandler != nil {
+		// Backreferences and CaptureGroupContextHandlers refer to the groups of the string being generated.
+		generate = func(state *generatorState) {
+			state.groups = map[int]string{}
+			generator.GenerateFunc(state)
+		}
+	}
+	if args.CaptureGroupHandlerE != nil {
+		// A string aborted by a failing handler doesn't abort the next one generated with the same state, e.g. by
+		// GenerateN.
+		abortable := generate
+		generate = func(state *generatorState) {
+			state.aborted, state.err = false, nil
+			abortable(state)
+		}
+	}
+	root := &internalGenerator{
+		Name:   generator.Name,
+		regexp: generator.regexp,
+		args:   args,
+	}
+	if args.WhitespacePolicy == AsIs && args.ChecksumScheme == NoChecksum && args.OnRune == nil {
+		// Nothing to do with the whole output, so write it as it is generated.
+		root.GenerateFunc = generate
+		return root
+	}
+
+	inner := &internalGenerator{GenerateFunc: generate}
+	root.GenerateFunc = func(state *generatorState) {
+		result := args.ChecksumScheme.apply(args.WhitespacePolicy.apply(inner.generateString(state)))
+		if args.OnRune != nil {
+			for _, r := range result {
+				args.OnRune(r)
+			}
+		}
+		state.out.WriteString(result)
+	}
+	return root
 }
 
 // Generator that does nothing.
 func noop(regexp *syntax.Regexp, _ *GeneratorArgs) (*internalGenerator, error) {
-	return &internalGenerator{regexp.String(), func() string {
-		return ""
-	}}, nil
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(*generatorState) {}}, nil
 }
 
 func opEmptyMatch(regexp *syntax.Regexp, _ *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpEmptyMatch)
-	return &internalGenerator{regexp.String(), func() string {
-		return ""
-	}}, nil
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(*generatorState) {}}, nil
 }
 
-func opLiteral(regexp *syntax.Regexp, _ *GeneratorArgs) (*internalGenerator, error) {
+func opLiteral(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpLiteral)
-	return &internalGenerator{regexp.String(), func() string {
-		return runesToString(regexp.Rune...)
+	if args.backreferences {
+		if generator, err := createBackreferenceGenerator(regexp, args); generator != nil || err != nil {
+			return generator, err
+		}
+	}
+	if args.Placeholders {
+		if generator := createPlaceholderGenerator(regexp); generator != nil {
+			return generator, nil
+		}
+	}
+	if generator := createFoldCaseGenerator(regexp); generator != nil && !args.Deterministic {
+		return generator, nil
+	}
+	literal := runesToString(regexp.Rune...)
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+		state.out.WriteString(literal)
 	}}, nil
 }
 
-func opAnyChar(regexp *syntax.Regexp, _ *GeneratorArgs) (*internalGenerator, error) {
+func opAnyChar(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpAnyChar)
-	return &internalGenerator{regexp.String(), func() string {
-		return runesToString(rand.Int31())
-	}}, nil
+	return createClassNodeGenerator(regexp, args)
 }
 
 func opAnyCharNotNl(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpAnyCharNotNL)
-	charClass := newCharClass(1, rune(math.MaxInt32))
-	return createCharClassGenerator(regexp.String(), charClass, args)
+	return createClassNodeGenerator(regexp, args)
 }
 
 func opQuest(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpQuest)
+	if probability, ok := optionalCaptureProbability(regexp, args); ok {
+		return createOptionalGenerator(regexp, args, probability)
+	}
 	return createRepeatingGenerator(regexp, args, 0, 1)
 }
 
+// optionalCaptureProbability returns the probability configured for an optional capture group, e.g. `(\w+)?` or
+// `(\w+){0,1}`, if there is one.
+func optionalCaptureProbability(regexp *syntax.Regexp, args *GeneratorArgs) (float64, bool) {
+	if len(regexp.Sub) != 1 || regexp.Sub[0].Op != syntax.OpCapture || args.Deterministic {
+		return 0, false
+	}
+	probability, ok := args.OptionalProbabilities[regexp.Sub[0].Cap-1]
+	return probability, ok
+}
+
 func opStar(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpStar)
 	return createRepeatingGenerator(regexp, args, noBound, noBound)
@@ -148,6 +474,15 @@ func opPlus(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, err
 
 func opRepeat(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpRepeat)
+	if regexp.Min == 0 && regexp.Max == 1 {
+		if probability, ok := optionalCaptureProbability(regexp, args); ok {
+			return createOptionalGenerator(regexp, args, probability)
+		}
+	}
+	if regexp.Min == 0 && regexp.Max == noBound {
+		// Like "*", generated at least MinUnboundedRepeatCount times.
+		return createRepeatingGenerator(regexp, args, noBound, noBound)
+	}
 	return createRepeatingGenerator(regexp, args, regexp.Min, regexp.Max)
 }
 
@@ -155,7 +490,15 @@ func opRepeat(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, e
 // classes that respect it.
 func opCharClass(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpCharClass)
-	charClass := parseCharClass(regexp.Rune)
+	return createClassNodeGenerator(regexp, args)
+}
+
+// createClassNodeGenerator returns a generator for a character class or "." node.
+func createClassNodeGenerator(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
+	charClass := generatedClass(regexp, args)
+	if charClass == nil {
+		return nil, generatorError(nil, "/%s/ has no runes left to generate once restricted by GeneratorArgs", regexp)
+	}
 	return createCharClassGenerator(regexp.String(), charClass, args)
 }
 
@@ -167,29 +510,65 @@ func opConcat(regexp *syntax.Regexp, genArgs *GeneratorArgs) (*internalGenerator
 		return nil, generatorError(err, "error creating generators for concat pattern /%s/", regexp)
 	}
 
-	return &internalGenerator{regexp.String(), func() string {
-		var result bytes.Buffer
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
 		for _, generator := range generators {
-			result.WriteString(generator.Generate())
+			if state.cancelled() {
+				return
+			}
+			generator.GenerateFunc(state)
 		}
-		return result.String()
 	}}, nil
 }
 
 func opAlternate(regexp *syntax.Regexp, genArgs *GeneratorArgs) (*internalGenerator, error) {
 	enforceOp(regexp, syntax.OpAlternate)
 
-	generators, err := newGenerators(regexp.Sub, genArgs)
+	if genArgs.MaxAlternationBranches > 0 && len(regexp.Sub) > genArgs.MaxAlternationBranches {
+		return nil, generatorError(ErrTooManyAlternationBranches, "/%s/ has %d branches, MaxAlternationBranches is %d",
+			regexp, len(regexp.Sub), genArgs.MaxAlternationBranches)
+	}
+
+	// Skip branches that can't match anything, e.g. `[^\x00-\x{10FFFF}]`.
+	var branches []*syntax.Regexp
+	var branchIndexes []int
+	var branchWeights []float64
+	weights, weighted := genArgs.AlternationWeights[regexp.String()]
+	for i, sub := range regexp.Sub {
+		if unmatchable(sub) == nil {
+			branches = append(branches, sub)
+			branchIndexes = append(branchIndexes, i)
+			if weighted {
+				branchWeights = append(branchWeights, weights[i])
+			}
+		}
+	}
+
+	generators, err := newGenerators(branches, genArgs)
 	if err != nil {
 		return nil, generatorError(err, "error creating generators for alternate pattern /%s/", regexp)
 	}
 
 	numGens := len(generators)
+	choose := func(rng *rand.Rand) int { return rng.Intn(numGens) }
+	if genArgs.Deterministic {
+		choose = func(*rand.Rand) int { return 0 }
+	} else if weighted {
+		choose = floatWeightedChoice(branchWeights)
+	} else if genArgs.Uniform {
+		log2Weights := make([]float64, numGens)
+		for i, branch := range branches {
+			log2Weights[i] = log2Matches(branch, genArgs)
+		}
+		choose = floatWeightedChoice(relativeWeights(log2Weights))
+	}
+
+	stats, name := genArgs.stats, regexp.String()
 
-	return &internalGenerator{regexp.String(), func() string {
-		i := rand.Intn(numGens)
+	return &internalGenerator{Name: name, GenerateFunc: func(state *generatorState) {
+		i := choose(state.rng)
 		generator := generators[i]
-		return generator.Generate()
+		generator.GenerateFunc(state)
+		stats.branch(name, branchIndexes[i], len(regexp.Sub))
 	}}, nil
 }
 
@@ -200,6 +579,16 @@ func opCapture(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator,
 		return nil, err
 	}
 
+	if value, ok := args.FixedGroups[regexp.Name]; ok && regexp.Name != "" {
+		index := regexp.Cap - 1
+		return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+			state.writeCapture(index, regexp.Name, value, len(state.spans))
+		}}, nil
+	}
+	if pool, ok := args.GroupValuePools[regexp.Name]; ok && regexp.Name != "" {
+		return createGroupPoolGenerator(regexp, pool, args), nil
+	}
+
 	groupRegexp := regexp.Sub[0]
 	generator, err := newGenerator(groupRegexp, args)
 	if err != nil {
@@ -209,8 +598,45 @@ func opCapture(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator,
 	// Group indices are 0-based, but index 0 is the whole expression.
 	index := regexp.Cap - 1
 
-	return &internalGenerator{regexp.String(), func() string {
-		return args.CaptureGroupHandler(index, regexp.Name, groupRegexp, generator, args)
+	if args.CaptureGroupContextHandler != nil {
+		return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+			group := &CaptureGroupContext{
+				Index:       index,
+				Name:        regexp.Name,
+				Group:       groupRegexp,
+				Generator:   generator.bind(state),
+				Args:        args,
+				Values:      make(map[int]string, len(state.groups)),
+				NamedValues: map[string]string{},
+			}
+			for i, value := range state.groups {
+				group.Values[i] = value
+				if name := args.capNames[i+1]; name != "" {
+					group.NamedValues[name] = value
+				}
+			}
+			nested := len(state.spans)
+			value := args.CaptureGroupContextHandler(group)
+			state.writeCapture(index, regexp.Name, value, nested)
+		}}, nil
+	}
+
+	if args.CaptureGroupHandlerE != nil {
+		return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+			nested := len(state.spans)
+			value, err := args.CaptureGroupHandlerE(index, regexp.Name, groupRegexp, generator.bind(state), args)
+			if err != nil {
+				state.abort(generatorError(err, "capture group %d of /%s/ failed", index, regexp))
+				return
+			}
+			state.writeCapture(index, regexp.Name, value, nested)
+		}}, nil
+	}
+
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+		nested := len(state.spans)
+		value := args.CaptureGroupHandler(index, regexp.Name, groupRegexp, generator.bind(state), args)
+		state.writeCapture(index, regexp.Name, value, nested)
 	}}, nil
 }
 
@@ -234,11 +660,25 @@ func enforceSingleSub(regexp *syntax.Regexp) error {
 	return nil
 }
 
-func createCharClassGenerator(name string, charClass *tCharClass, _ *GeneratorArgs) (*internalGenerator, error) {
-	return &internalGenerator{name, func() string {
-		i := rand.Int31n(charClass.TotalSize)
-		r := charClass.GetRuneAt(i)
-		return runesToString(r)
+func createCharClassGenerator(name string, charClass *tCharClass, args *GeneratorArgs) (*internalGenerator, error) {
+	if args.Deterministic {
+		r := args.DigitScript.convert(lowestRune(charClass.runeRanges()))
+		return &internalGenerator{Name: name, GenerateFunc: func(state *generatorState) {
+			state.out.WriteRune(r)
+		}}, nil
+	}
+	if args.ClassAlphabetSize > 0 {
+		charClass = charClass.subset(args.ClassAlphabetSize, args.rng)
+	}
+	if args.UTF8ByteLengthMix != ([4]float64{}) {
+		if generator := createUTF8MixGenerator(name, charClass, args); generator != nil {
+			return generator, nil
+		}
+	}
+
+	return &internalGenerator{Name: name, GenerateFunc: func(state *generatorState) {
+		i := state.rng.Int31n(charClass.TotalSize)
+		state.out.WriteRune(args.DigitScript.convert(charClass.GetRuneAt(i)))
 	}}, nil
 }
 
@@ -248,25 +688,82 @@ func createRepeatingGenerator(regexp *syntax.Regexp, genArgs *GeneratorArgs, min
 		return nil, err
 	}
 
+	if unmatchable(regexp.Sub[0]) != nil {
+		// Can only be repeated 0 times, which NewGenerator made sure is allowed.
+		return &internalGenerator{Name: regexp.String(), GenerateFunc: func(*generatorState) {}}, nil
+	}
+
 	generator, err := newGenerator(regexp.Sub[0], genArgs)
 	if err != nil {
 		return nil, generatorError(err, "failed to create generator for subexpression: /%s/", regexp)
 	}
 
+	bounded := max != noBound
 	if min == noBound {
 		min = int(genArgs.MinUnboundedRepeatCount)
 	}
 	if max == noBound {
-		max = int(genArgs.MaxUnboundedRepeatCount)
+		min, max = genArgs.unboundedRepeatBounds(regexp.Op, min)
+	}
+	stats, name := genArgs.stats, regexp.String()
+
+	if regexp.Op == syntax.OpRepeat && bounded && genArgs.RepeatCountSampler == nil && !genArgs.Uniform &&
+		!genArgs.Deterministic {
+		// Like the parser's Simplify spells bounded ranges, e.g. `aa(?:a(?:a)?)?` for `a{2,4}`: every instance past
+		// the minimum is generated with probability 1/2, if the one before it was.
+		return &internalGenerator{Name: name, GenerateFunc: func(state *generatorState) {
+			n := 0
+			for ; n < min && !state.cancelled(); n++ {
+				generator.GenerateFunc(state)
+			}
+			for n < max && !state.cancelled() && state.rng.Intn(2) == 1 {
+				generator.GenerateFunc(state)
+				n++
+			}
+			stats.repeat(name, n)
+		}}, nil
+	}
+
+	choose := func(rng *rand.Rand) int { return UniformRepeat(min, max, rng) }
+	if genArgs.Deterministic {
+		n := min
+		if regexp.Op == syntax.OpQuest {
+			n = max
+		}
+		choose = func(*rand.Rand) int { return n }
+	} else if genArgs.RepeatCountSampler != nil {
+		choose = func(rng *rand.Rand) int { return genArgs.RepeatCountSampler.sample(min, max, rng) }
+	} else if genArgs.Uniform {
+		choose = uniformRepeatChoice(log2Matches(regexp.Sub[0], genArgs), min, max)
+	}
+
+	return &internalGenerator{Name: name, GenerateFunc: func(state *generatorState) {
+		n := choose(state.rng)
+		for i := 0; i < n && !state.cancelled(); i++ {
+			generator.GenerateFunc(state)
+		}
+		stats.repeat(name, n)
+	}}, nil
+}
+
+// Returns a generator that will run the generator for r's sub-expression with the given probability.
+func createOptionalGenerator(regexp *syntax.Regexp, genArgs *GeneratorArgs, probability float64) (*internalGenerator, error) {
+	if err := enforceSingleSub(regexp); err != nil {
+		return nil, err
+	}
+
+	if unmatchable(regexp.Sub[0]) != nil {
+		return &internalGenerator{Name: regexp.String(), GenerateFunc: func(*generatorState) {}}, nil
 	}
 
-	return &internalGenerator{regexp.String(), func() string {
-		n := min + rand.Intn(max-min+1)
+	generator, err := newGenerator(regexp.Sub[0], genArgs)
+	if err != nil {
+		return nil, generatorError(err, "failed to create generator for subexpression: /%s/", regexp)
+	}
 
-		var result bytes.Buffer
-		for i := 0; i < n; i++ {
-			result.WriteString(generator.Generate())
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+		if state.rng.Float64() < probability {
+			generator.GenerateFunc(state)
 		}
-		return result.String()
 	}}, nil
 }
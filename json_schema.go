@@ -0,0 +1,148 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"encoding/json"
+	"math"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// Number of strings generated while looking for one within MinTotalLength and MaxTotalLength.
+const maxLengthAttempts = 1000
+
+/*
+GeneratorFromJSONSchemaPattern creates a generator for a JSON Schema string field definition, e.g. decoded from
+
+	{"type": "string", "pattern": "^[a-z]+$", "minLength": 3, "maxLength": 8}
+
+The "pattern" key is required. Generated strings match it, and have a length in code points within "minLength"
+and "maxLength" if present, which are passed on as MinTotalLength and MaxTotalLength. GeneratorFromJSONSchemaPattern
+returns an error if the pattern can't generate strings of such lengths.
+
+Patterns are parsed with syntax.Perl flags, which covers the common subset of the ECMA-262 syntax JSON Schema uses.
+*/
+func GeneratorFromJSONSchemaPattern(schemaField map[string]interface{}) (Generator, error) {
+	pattern, ok := schemaField["pattern"].(string)
+	if !ok {
+		return nil, generatorError(nil, "JSON Schema field has no string \"pattern\"")
+	}
+
+	minLength, err := schemaLength(schemaField, "minLength", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxLength, err := schemaLength(schemaField, "maxLength", math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	if minLength > maxLength {
+		return nil, generatorError(nil, "minLength(%d) > maxLength(%d)", minLength, maxLength)
+	}
+
+	args := &GeneratorArgs{Flags: syntax.Perl, MinTotalLength: minLength}
+	if maxLength < math.MaxInt32 {
+		args.MaxTotalLength = maxLength
+	}
+
+	generator, err := NewGenerator(pattern, args)
+	if err != nil {
+		return nil, err
+	}
+	if maxLength == 0 {
+		// A MaxTotalLength of 0 means no maximum, so only the empty string has to be picked out here.
+		return newLengthBoundedGenerator(generator.(*internalGenerator), 0, 0)
+	}
+	return generator, nil
+}
+
+// schemaLength reads a non-negative integer from field[key], returning def if it is absent.
+func schemaLength(field map[string]interface{}, key string, def int) (int, error) {
+	value, ok := field[key]
+	if !ok {
+		return def, nil
+	}
+
+	var length float64
+	switch v := value.(type) {
+	case float64:
+		length = v
+	case int:
+		length = float64(v)
+	case int64:
+		length = float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, generatorError(err, "invalid %q", key)
+		}
+		length = f
+	default:
+		return 0, generatorError(nil, "%q must be a number, was %T", key, value)
+	}
+
+	if length < 0 || length != math.Trunc(length) || length > math.MaxInt32 {
+		return 0, generatorError(nil, "%q must be a non-negative integer, was %v", key, length)
+	}
+	return int(length), nil
+}
+
+// newLengthBoundedGenerator wraps generator to only return strings of between min and max runes.
+func newLengthBoundedGenerator(generator *internalGenerator, min, max int) (*internalGenerator, error) {
+	fits := func(s string) bool {
+		n := utf8.RuneCountInString(s)
+		return n >= min && n <= max
+	}
+
+	notFound := func() error {
+		if max == math.MaxInt32 {
+			return generatorError(nil, "/%s/ did not generate a string at least %d long in %d attempts",
+				generator, min, maxLengthAttempts)
+		}
+		return generatorError(nil, "/%s/ did not generate a string between %d and %d long in %d attempts",
+			generator, min, max, maxLengthAttempts)
+	}
+
+	// Make sure fitting strings can be generated at all.
+	found := false
+	for i := 0; i < maxLengthAttempts && !found; i++ {
+		found = fits(generator.Generate())
+	}
+	if !found {
+		return nil, notFound()
+	}
+
+	return &internalGenerator{
+		Name:   generator.Name,
+		regexp: generator.regexp,
+		args:   generator.args,
+		GenerateFunc: func(state *generatorState) {
+			s := generator.generateString(state)
+			for i := 1; i < maxLengthAttempts && !fits(s) && !state.cancelled(); i++ {
+				// Only record the groups of the string returned.
+				state.resetCaptures()
+				s = generator.generateString(state)
+			}
+			if !fits(s) {
+				state.fail(notFound())
+			}
+			state.out.WriteString(s)
+		},
+	}, nil
+}
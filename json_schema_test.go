@@ -0,0 +1,86 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGeneratorFromJSONSchemaPattern(t *testing.T) {
+	t.Parallel()
+
+	schemaField := func(t *testing.T, schema string) map[string]interface{} {
+		var field map[string]interface{}
+		if err := json.Unmarshal([]byte(schema), &field); err != nil {
+			t.Fatal(err)
+		}
+		return field
+	}
+
+	t.Run("Respects pattern and length", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			schema   string
+			min, max int
+		}{
+			{`{"pattern": "^[a-z]+$", "minLength": 3, "maxLength": 8}`, 3, 8},
+			{`{"pattern": "^\\d{5,12}$", "minLength": 8}`, 8, 12},
+			{`{"pattern": "^é*$", "maxLength": 2}`, 0, 2},
+			{`{"pattern": "^x?$", "maxLength": 0}`, 0, 0},
+			{`{"pattern": "^[a-z]{2,}-[0-9]+$", "minLength": 15, "maxLength": 15}`, 15, 15},
+		} {
+			generator, err := GeneratorFromJSONSchemaPattern(schemaField(t, tc.schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pattern := regexp.MustCompile(schemaField(t, tc.schema)["pattern"].(string))
+
+			for i := 0; i < SampleSize; i++ {
+				s := generator.Generate()
+				if !pattern.MatchString(s) {
+					t.Fatalf("%q does not match %s", s, tc.schema)
+				}
+				if n := utf8.RuneCountInString(s); n < tc.min || n > tc.max {
+					t.Fatalf("%q has wrong length for %s", s, tc.schema)
+				}
+			}
+		}
+	})
+
+	t.Run("Rejects invalid schemas", func(t *testing.T) {
+		t.Parallel()
+
+		for _, schema := range []string{
+			`{"type": "string"}`,
+			`{"pattern": 42}`,
+			`{"pattern": "a", "minLength": -1}`,
+			`{"pattern": "a", "minLength": "3"}`,
+			`{"pattern": "a", "minLength": 3, "maxLength": 2}`,
+			`{"pattern": "^a{1,3}$", "minLength": 5}`,
+			`{"pattern": "a("}`,
+		} {
+			if _, err := GeneratorFromJSONSchemaPattern(schemaField(t, schema)); err == nil {
+				t.Fatalf("%s should be rejected", schema)
+			}
+		}
+	})
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math"
+	"regexp/syntax"
+)
+
+// lengthBounds returns the minimum and maximum number of runes in strings generated from regexp, or math.MaxInt32
+// for maximums at least that large.
+func lengthBounds(regexp *syntax.Regexp, args *GeneratorArgs) (min, max int) {
+	switch regexp.Op {
+	case syntax.OpLiteral:
+		return len(regexp.Rune), len(regexp.Rune)
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return 1, 1
+	case syntax.OpCapture:
+		return lengthBounds(regexp.Sub[0], args)
+	case syntax.OpConcat:
+		for _, sub := range regexp.Sub {
+			subMin, subMax := lengthBounds(sub, args)
+			min, max = saturatingAdd(min, subMin), saturatingAdd(max, subMax)
+		}
+		return min, max
+	case syntax.OpAlternate:
+		first := true
+		for _, sub := range regexp.Sub {
+			if unmatchable(sub) != nil {
+				continue
+			}
+			subMin, subMax := lengthBounds(sub, args)
+			if first || subMin < min {
+				min, first = subMin, false
+			}
+			if subMax > max {
+				max = subMax
+			}
+		}
+		return min, max
+	case syntax.OpQuest, syntax.OpStar, syntax.OpPlus, syntax.OpRepeat:
+		if unmatchable(regexp.Sub[0]) != nil {
+			return 0, 0
+		}
+		subMin, subMax := lengthBounds(regexp.Sub[0], args)
+		repeatMin, repeatMax := repeatBounds(regexp, args)
+		return saturatingMul(subMin, repeatMin), saturatingMul(subMax, repeatMax)
+	}
+	return 0, 0
+}
+
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt32-b {
+		return math.MaxInt32
+	}
+	return a + b
+}
+
+func saturatingMul(a, b int) int {
+	if a != 0 && b > math.MaxInt32/a {
+		return math.MaxInt32
+	}
+	return a * b
+}
+
+// unboundedRepeatBounds returns the minimum and maximum number of instances to generate for an unbounded repeat
+// expression with operator op (e.g. syntax.OpStar) and the given minimum.
+func (a *GeneratorArgs) unboundedRepeatBounds(op syntax.Op, min int) (int, int) {
+	if min < a.unboundedRepeatFloor {
+		min = a.unboundedRepeatFloor
+	}
+	max := int(a.unboundedRepeatMax(op))
+	if a.MaxTotalLength > 0 && max > a.unboundedRepeatCeiling {
+		max = a.unboundedRepeatCeiling
+	}
+	if max < min {
+		max = min
+	}
+	return min, max
+}
+
+// unboundedRepeatMax returns the configured maximum number of instances to generate for unbounded repeat
+// expressions with operator op.
+func (a *GeneratorArgs) unboundedRepeatMax(op syntax.Op) uint {
+	var max uint
+	switch op {
+	case syntax.OpStar:
+		max = a.StarMax
+	case syntax.OpPlus:
+		max = a.PlusMax
+	case syntax.OpRepeat:
+		max = a.OpenRangeMax
+	}
+	if max == 0 {
+		max = a.MaxUnboundedRepeatCount
+	}
+	return max
+}
+
+// largestUnboundedRepeatMax returns the largest maximum number of instances to generate for any unbounded repeat
+// expression.
+func (a *GeneratorArgs) largestUnboundedRepeatMax() int {
+	largest := uint(0)
+	for _, op := range []syntax.Op{syntax.OpStar, syntax.OpPlus, syntax.OpRepeat} {
+		if max := a.unboundedRepeatMax(op); max > largest {
+			largest = max
+		}
+	}
+	return int(largest)
+}
+
+/*
+raiseUnboundedRepeatFloor raises the minimum number of instances generated for the unbounded repeat expressions of
+regexp until its shortest strings are MinTotalLength runes long, if they aren't already. It returns
+ErrUnsatisfiable if even its longest strings are shorter.
+*/
+func (a *GeneratorArgs) raiseUnboundedRepeatFloor(regexp *syntax.Regexp) error {
+	min, max := lengthBounds(regexp, a)
+	if max < a.MinTotalLength {
+		return generatorError(ErrUnsatisfiable, "/%s/ generates at most %d runes, MinTotalLength is %d",
+			regexp, max, a.MinTotalLength)
+	}
+	if min >= a.MinTotalLength {
+		return nil
+	}
+
+	// The shortest strings only get longer as the floor rises, so search for the lowest floor that's enough.
+	// If none is, strings too short are regenerated.
+	low, high := 0, a.largestUnboundedRepeatMax()
+	if a.MaxTotalLength > 0 && high > a.unboundedRepeatCeiling {
+		high = a.unboundedRepeatCeiling
+	}
+	for low < high {
+		floor := low + (high-low)/2
+		a.unboundedRepeatFloor = floor
+		if floorMin, _ := lengthBounds(regexp, a); floorMin < a.MinTotalLength {
+			low = floor + 1
+		} else {
+			high = floor
+		}
+	}
+	a.unboundedRepeatFloor = low
+	return nil
+}
+
+/*
+lowerUnboundedRepeatCeiling lowers the maximum number of instances generated for the unbounded repeat expressions of
+regexp until its longest strings are at most MaxTotalLength runes long, if they aren't already. It returns
+ErrUnsatisfiable if even its shortest strings are longer, e.g. because of the literal text in it.
+*/
+func (a *GeneratorArgs) lowerUnboundedRepeatCeiling(regexp *syntax.Regexp) error {
+	a.unboundedRepeatCeiling = a.largestUnboundedRepeatMax()
+	min, max := lengthBounds(regexp, a)
+	if min > a.MaxTotalLength {
+		return generatorError(ErrUnsatisfiable, "/%s/ generates at least %d runes, MaxTotalLength is %d",
+			regexp, min, a.MaxTotalLength)
+	}
+	if max <= a.MaxTotalLength {
+		return nil
+	}
+
+	// The longest strings only get shorter as the ceiling lowers, so search for the highest ceiling that's low
+	// enough. If none is, e.g. because of long bounded repeats, strings too long are regenerated.
+	low, high := 0, a.unboundedRepeatCeiling
+	for low < high {
+		ceiling := low + (high-low+1)/2
+		a.unboundedRepeatCeiling = ceiling
+		if _, ceilingMax := lengthBounds(regexp, a); ceilingMax > a.MaxTotalLength {
+			high = ceiling - 1
+		} else {
+			low = ceiling
+		}
+	}
+	a.unboundedRepeatCeiling = low
+	return nil
+}
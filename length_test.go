@@ -0,0 +1,184 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"errors"
+	"regexp/syntax"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMinTotalLength(t *testing.T) {
+	t.Parallel()
+
+	generatesAtLeast := func(t *testing.T, pattern string, args *GeneratorArgs) {
+		generator, err := NewGenerator(pattern, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			if s := generator.Generate(); utf8.RuneCountInString(s) < args.MinTotalLength {
+				t.Fatalf("%q is shorter than %d", s, args.MinTotalLength)
+			}
+		}
+	}
+
+	t.Run("Regenerates short strings", func(t *testing.T) {
+		t.Parallel()
+
+		generatesAtLeast(t, `a{1,5}`, &GeneratorArgs{MinTotalLength: 4})
+		generatesAtLeast(t, `(ab|c)(é|[0-9]{2,3})`, &GeneratorArgs{MinTotalLength: 4})
+	})
+
+	t.Run("Raises unbounded repeats", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{MinTotalLength: 4000}
+		generatesAtLeast(t, `x[a-z]*`, args)
+
+		generator, _ := NewGenerator(`x[a-z]*`, args)
+		if floor := generator.(*internalGenerator).args.unboundedRepeatFloor; floor != 3999 {
+			t.Fatal("should be equal:", floor, 3999)
+		}
+		generator, _ = NewGenerator(`x{2}[a-z]*`, &GeneratorArgs{MinTotalLength: 2})
+		if floor := generator.(*internalGenerator).args.unboundedRepeatFloor; floor != 0 {
+			t.Fatal("should be equal:", floor, 0)
+		}
+	})
+
+	t.Run("Unsatisfiable", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`a{1,5}`, `(a|bc)?`, `x\w*`} {
+			_, err := NewGenerator(pattern, &GeneratorArgs{
+				Flags:                   syntax.Perl,
+				MinTotalLength:          6,
+				MaxUnboundedRepeatCount: 4,
+			})
+			if !errors.Is(err, ErrUnsatisfiable) {
+				t.Fatalf("/%s/ should be ErrUnsatisfiable, was %v", pattern, err)
+			}
+		}
+
+		if _, err := NewGenerator(`a`, &GeneratorArgs{MinTotalLength: -1}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+func TestMaxTotalLength(t *testing.T) {
+	t.Parallel()
+
+	generatesAtMost := func(t *testing.T, pattern string, args *GeneratorArgs) {
+		generator, err := NewGenerator(pattern, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			s, err := generator.GenerateE()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if utf8.RuneCountInString(s) > args.MaxTotalLength {
+				t.Fatalf("%q is longer than %d", s, args.MaxTotalLength)
+			}
+		}
+	}
+
+	t.Run("Regenerates long strings", func(t *testing.T) {
+		t.Parallel()
+
+		generatesAtMost(t, `a{1,5}`, &GeneratorArgs{MaxTotalLength: 2})
+		generatesAtMost(t, `(ab|c)(é|[0-9]{2,3})`, &GeneratorArgs{MaxTotalLength: 3})
+	})
+
+	t.Run("Lowers unbounded repeats", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{MaxTotalLength: 7, MinUnboundedRepeatCount: 2, MaxUnboundedRepeatCount: 100}
+		generatesAtMost(t, `xy[a-z]*`, args)
+
+		generator, _ := NewGenerator(`xy[a-z]*`, args)
+		if ceiling := generator.(*internalGenerator).args.unboundedRepeatCeiling; ceiling != 5 {
+			t.Fatal("should be equal:", ceiling, 5)
+		}
+		generator, _ = NewGenerator(`(ab)+`, &GeneratorArgs{MaxTotalLength: 5})
+		if ceiling := generator.(*internalGenerator).args.unboundedRepeatCeiling; ceiling != 2 {
+			t.Fatal("should be equal:", ceiling, 2)
+		}
+	})
+
+	t.Run("Unsatisfiable", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`abcdef`, `a{6,8}`, `x\w{3}(yz|[0-9]{2})`, `abc\d+def`} {
+			_, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, MaxTotalLength: 5})
+			if !errors.Is(err, ErrUnsatisfiable) {
+				t.Fatalf("/%s/ should be ErrUnsatisfiable, was %v", pattern, err)
+			}
+		}
+
+		if _, err := NewGenerator(`a`, &GeneratorArgs{MaxTotalLength: -1}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if _, err := NewGenerator(`a*`, &GeneratorArgs{MinTotalLength: 3, MaxTotalLength: 2}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+
+	t.Run("Works with MinTotalLength", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{MinTotalLength: 4, MaxTotalLength: 6}
+		generatesAtMost(t, `a*b`, args)
+
+		generator, _ := NewGenerator(`a*b`, args)
+		for i := 0; i < SampleSize; i++ {
+			if s := generator.Generate(); utf8.RuneCountInString(s) < args.MinTotalLength {
+				t.Fatalf("%q is shorter than %d", s, args.MinTotalLength)
+			}
+		}
+	})
+
+	t.Run("GenerateE returns an error for strings too long", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		generator, err := NewGenerator(`(a)`, &GeneratorArgs{
+			MaxTotalLength: 3,
+			CaptureGroupHandler: func(_ int, _ string, _ *syntax.Regexp, _ Generator, _ *GeneratorArgs) string {
+				// Fit once, while NewGenerator checks, then never again.
+				calls++
+				if calls == 1 {
+					return "a"
+				}
+				return "aaaa"
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, err := generator.GenerateE(); err == nil {
+			t.Fatalf("err should not be nil, generated %q", s)
+		}
+		if s := generator.Generate(); s != "aaaa" {
+			t.Fatal("should be equal:", s, "aaaa")
+		}
+	})
+}
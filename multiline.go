@@ -0,0 +1,84 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "regexp/syntax"
+
+// Maximum length of the lines generated by SurroundingLines, in runes.
+const maxSurroundingLineLength = 8
+
+// anchoredAt reports whether regexp always begins (or ends, if last) with an op assertion, e.g. `^` for
+// syntax.OpBeginLine.
+func anchoredAt(regexp *syntax.Regexp, op syntax.Op, last bool) bool {
+	switch regexp.Op {
+	case op:
+		return true
+	case syntax.OpCapture:
+		return anchoredAt(regexp.Sub[0], op, last)
+	case syntax.OpConcat:
+		if len(regexp.Sub) == 0 {
+			return false
+		}
+		if last {
+			return anchoredAt(regexp.Sub[len(regexp.Sub)-1], op, last)
+		}
+		return anchoredAt(regexp.Sub[0], op, last)
+	}
+	return false
+}
+
+/*
+newSurroundingLinesGenerator wraps generator to generate up to max lines of printable ASCII before the string it
+generates if its expression begins with a multiline `^`, and after it if it ends with a multiline `$`, e.g.
+"ab c\nfoo\nx" for `(?m)^foo$`. It returns generator itself if neither is the case.
+*/
+func newSurroundingLinesGenerator(generator *internalGenerator, regexp *syntax.Regexp, max int) *internalGenerator {
+	before, after := anchoredAt(regexp, syntax.OpBeginLine, false), anchoredAt(regexp, syntax.OpEndLine, true)
+	if !before && !after {
+		return generator
+	}
+
+	writeLines := func(state *generatorState, lineFirst bool) {
+		for n := state.rng.Intn(max + 1); n > 0; n-- {
+			if !lineFirst {
+				state.out.WriteRune('\n')
+			}
+			for length := 1 + state.rng.Intn(maxSurroundingLineLength); length > 0; length-- {
+				state.out.WriteRune(rune(' ' + state.rng.Intn('~'-' '+1)))
+			}
+			if lineFirst {
+				state.out.WriteRune('\n')
+			}
+		}
+	}
+
+	return &internalGenerator{
+		Name:   generator.Name,
+		regexp: generator.regexp,
+		args:   generator.args,
+		GenerateFunc: func(state *generatorState) {
+			if before {
+				writeLines(state, true)
+			}
+			generator.GenerateFunc(state)
+			if after && !state.cancelled() {
+				writeLines(state, false)
+			}
+		},
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestSurroundingLines(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Surrounds anchored matches with lines", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`(?m)^foo$`, `(?m)^\d+ items?$`, `(?m)^(a|b)$\n^c$`} {
+			generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, SurroundingLines: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			compiled := regexp.MustCompile(pattern)
+			surrounded := false
+			for i := 0; i < SampleSize; i++ {
+				s := generator.Generate()
+				if !compiled.MatchString(s) {
+					t.Fatalf("%q generated from /%s/ does not contain a match", s, pattern)
+				}
+				if n := strings.Count(s, "\n") - strings.Count(pattern, `\n`); n > 6 {
+					t.Fatalf("%q generated from /%s/ has %d surrounding lines", s, pattern, n)
+				}
+				surrounded = surrounded || !regexp.MustCompile(`^(?:`+pattern+`)$`).MatchString(s)
+			}
+			if !surrounded {
+				t.Fatalf("/%s/ never generated surrounding lines", pattern)
+			}
+		}
+	})
+
+	t.Run("Only surrounds line anchors", func(t *testing.T) {
+		t.Parallel()
+
+		for pattern, expected := range map[string]string{`(?m)foo$`: `^foo(\n[ -~]+)*$`, `(?m)^foo`: `^([ -~]+\n)*foo$`,
+			`^foo$`: `^foo$`, `(?m)a\n^b`: `^a\nb$`} {
+			generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, SurroundingLines: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			compiled := regexp.MustCompile(expected)
+			for i := 0; i < SampleSize; i++ {
+				if s := generator.Generate(); !compiled.MatchString(s) {
+					t.Fatalf("%q generated from /%s/ should match /%s/", s, pattern, expected)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"unicode/utf8"
+)
+
+// Number of strings GenerateNonMatching generates looking for one with an edit that breaks its match.
+const maxNonMatchingAttempts = 10
+
+// Runes tried as substitutions and insertions when looking for an edit that breaks a match.
+var editRunes = []rune{'\n', ' ', '0', 'A', 'a', '~', '-', 'é'}
+
+/*
+GenerateWithFailingEdit generates a string, and a copy of it with a single character deleted, substituted or inserted
+so that it no longer matches the generator's expression. pos is the byte offset of the edit in valid.
+
+Positions are tried starting from a random one. If no single edit makes the string fail to match
+(e.g. for `(?s).*`), broken is empty and pos is -1.
+*/
+func (gen *internalGenerator) GenerateWithFailingEdit() (valid string, broken string, pos int) {
+	valid = gen.Generate()
+
+	re, err := gen.fullRegexp()
+	if err != nil {
+		return valid, "", -1
+	}
+
+	// Rune boundaries, including the end of the string for insertions.
+	var offsets []int
+	for i := range valid {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(valid))
+
+	start := gen.args.rng.Intn(len(offsets))
+	for i := range offsets {
+		offset := offsets[(start+i)%len(offsets)]
+		for _, candidate := range singleEdits(valid, offset) {
+			if !re.MatchString(candidate) {
+				return valid, candidate, offset
+			}
+		}
+	}
+
+	return valid, "", -1
+}
+
+/*
+GenerateNonMatching returns a string that doesn't match the generator's expression, but is a single edit away from a
+string generated from it, e.g. to test validators with near misses. Strings are generated until one of them has such
+an edit, making at most 10 attempts, so it returns an error for expressions no edit breaks the match of, e.g.
+`(?s).*`.
+*/
+func (gen *internalGenerator) GenerateNonMatching() (string, error) {
+	if _, err := gen.fullRegexp(); err != nil {
+		return "", err
+	}
+	for i := 0; i < maxNonMatchingAttempts; i++ {
+		if _, broken, pos := gen.GenerateWithFailingEdit(); pos >= 0 {
+			return broken, nil
+		}
+	}
+	return "", generatorError(nil, "no single edit of %d strings generated from /%s/ fails to match it",
+		maxNonMatchingAttempts, gen)
+}
+
+// singleEdits returns the strings that are one deletion, substitution or insertion (of one of editRunes)
+// at byte offset away from s.
+func singleEdits(s string, offset int) []string {
+	prefix, rest := s[:offset], s[offset:]
+	var edits []string
+
+	if len(rest) > 0 {
+		r, size := utf8.DecodeRuneInString(rest)
+		suffix := rest[size:]
+		edits = append(edits, prefix+suffix)
+		for _, sub := range editRunes {
+			if sub != r {
+				edits = append(edits, prefix+string(sub)+suffix)
+			}
+		}
+	}
+	for _, ins := range editRunes {
+		edits = append(edits, prefix+string(ins)+rest)
+	}
+
+	return edits
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"testing"
+)
+
+func TestGenerateWithFailingEdit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Broken string fails to match", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`[a-z]{3}\d`, `foo|bar`, `x*`, `(ab)+c?`} {
+			generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl})
+			if err != nil {
+				t.Fatal(err)
+			}
+			full := regexp.MustCompile(`^(?:` + pattern + `)$`)
+
+			for i := 0; i < 100; i++ {
+				valid, broken, pos := generator.GenerateWithFailingEdit()
+				if !full.MatchString(valid) {
+					t.Fatalf("%q should match /%s/", valid, pattern)
+				}
+				if pos < 0 {
+					t.Fatalf("no failing edit found for %q", valid)
+				}
+				if full.MatchString(broken) {
+					t.Fatalf("%q should not match /%s/", broken, pattern)
+				}
+				if valid[:pos] != broken[:pos] {
+					t.Fatalf("%q and %q should share the prefix before %d", valid, broken, pos)
+				}
+			}
+		}
+	})
+
+	t.Run("Reports when no edit breaks the match", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`(?s).*`, &GeneratorArgs{
+			Flags:                   syntax.Perl,
+			MaxUnboundedRepeatCount: 5,
+		})
+		_, broken, pos := generator.GenerateWithFailingEdit()
+		if broken != "" || pos != -1 {
+			t.Fatalf("should not find an edit, got %q at %d", broken, pos)
+		}
+	})
+}
+
+func TestGenerateNonMatching(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Does not match", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`[a-z]{3}\d`, `foo|bar`, `x*`, `.*`, `(ab)+c?`} {
+			generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, MaxUnboundedRepeatCount: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			full := regexp.MustCompile(`^(?:` + pattern + `)$`)
+
+			for i := 0; i < 100; i++ {
+				s, err := generator.GenerateNonMatching()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if full.MatchString(s) {
+					t.Fatalf("%q should not match /%s/", s, pattern)
+				}
+			}
+		}
+	})
+
+	t.Run("Fails when every edit matches", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(?s).*`, &GeneratorArgs{Flags: syntax.Perl, MaxUnboundedRepeatCount: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := generator.GenerateNonMatching(); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
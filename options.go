@@ -0,0 +1,70 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "regexp/syntax"
+
+// Option sets one of the GeneratorArgs passed to NewGeneratorOpts.
+type Option func(args *GeneratorArgs) error
+
+// WithSeed seeds the generator's source, like GeneratorArgs.Seed.
+func WithSeed(seed int64) Option {
+	return func(args *GeneratorArgs) error {
+		args.Seed = &seed
+		return nil
+	}
+}
+
+// WithFlags sets the flags the expression is parsed with, like GeneratorArgs.Flags.
+func WithFlags(flags syntax.Flags) Option {
+	return func(args *GeneratorArgs) error {
+		args.Flags = flags
+		return nil
+	}
+}
+
+// WithMaxRepeat sets the maximum number of instances to generate for unbounded repeat expressions, like
+// GeneratorArgs.MaxUnboundedRepeatCount.
+func WithMaxRepeat(max int) Option {
+	return func(args *GeneratorArgs) error {
+		if max < 0 {
+			return generatorError(nil, "max repeat must not be negative, was %d", max)
+		}
+		args.MaxUnboundedRepeatCount = uint(max)
+		return nil
+	}
+}
+
+// WithCaptureHandler sets the function generating capture groups, like GeneratorArgs.CaptureGroupHandler.
+func WithCaptureHandler(handler CaptureGroupHandler) Option {
+	return func(args *GeneratorArgs) error {
+		args.CaptureGroupHandler = handler
+		return nil
+	}
+}
+
+// NewGeneratorOpts is like NewGenerator, with the GeneratorArgs set by opts, in order.
+func NewGeneratorOpts(pattern string, opts ...Option) (Generator, error) {
+	var args GeneratorArgs
+	for _, opt := range opts {
+		if err := opt(&args); err != nil {
+			return nil, err
+		}
+	}
+	return NewGenerator(pattern, &args)
+}
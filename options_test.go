@@ -0,0 +1,80 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"testing"
+)
+
+func ExampleNewGeneratorOpts() {
+	pattern := `\d{5}`
+
+	generator, _ := NewGeneratorOpts(pattern, WithFlags(syntax.Perl), WithSeed(42))
+
+	str := generator.Generate()
+
+	if matched, _ := regexp.MatchString("[[:digit:]]{5}", str); matched {
+		fmt.Println("Matches!")
+	}
+	// Output:
+	// Matches!
+}
+
+func TestNewGeneratorOpts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Generates like GeneratorArgs", func(t *testing.T) {
+		t.Parallel()
+
+		handler := func(index int, name string, group *syntax.Regexp, generator Generator, args *GeneratorArgs) string {
+			return "<" + generator.Generate() + ">"
+		}
+		seed := int64(7)
+		fromArgs, err := NewGenerator(`(\w+)-.*`, &GeneratorArgs{
+			Seed:                    &seed,
+			Flags:                   syntax.Perl,
+			MaxUnboundedRepeatCount: 5,
+			CaptureGroupHandler:     handler,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromOpts, err := NewGeneratorOpts(`(\w+)-.*`,
+			WithSeed(seed), WithFlags(syntax.Perl), WithMaxRepeat(5), WithCaptureHandler(handler))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < SampleSize; i++ {
+			if a, b := fromArgs.Generate(), fromOpts.Generate(); a != b {
+				t.Fatal("should be equal:", a, b)
+			}
+		}
+	})
+
+	t.Run("Fails on negative max repeat", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewGeneratorOpts(`a*`, WithMaxRepeat(-1)); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "unicode/utf8"
+
+// newSizeLimitedGenerator wraps generator to abort generating once its output would hold more than maxSize bytes.
+func newSizeLimitedGenerator(generator *internalGenerator, maxSize int) *internalGenerator {
+	return &internalGenerator{
+		Name:   generator.Name,
+		regexp: generator.regexp,
+		args:   generator.args,
+		GenerateFunc: func(state *generatorState) {
+			out := state.out
+			defer func() { state.out = out }()
+
+			state.maxSize, state.size, state.aborted = maxSize, 0, false
+			state.out = state.limitSize(out)
+			generator.GenerateFunc(state)
+		},
+	}
+}
+
+// limitSize returns out, counting what is written to it against state.maxSize if there is one.
+func (state *generatorState) limitSize(out output) output {
+	if state.maxSize == 0 {
+		return out
+	}
+	return &sizeLimitedOutput{out: out, state: state}
+}
+
+/*
+sizeLimitedOutput counts the bytes written to out in state.size. Strings generated into a buffer, e.g. for a
+capture group, are counted while the buffer holds them and again once they are written out, so state.size is the
+number of bytes held by every output at once. A write that would make it exceed state.maxSize is dropped, and
+aborts generation with ErrOutputTooLarge.
+*/
+type sizeLimitedOutput struct {
+	out   output
+	state *generatorState
+}
+
+func (o *sizeLimitedOutput) WriteString(s string) (int, error) {
+	if !o.fits(len(s)) {
+		return 0, nil
+	}
+	return o.out.WriteString(s)
+}
+
+func (o *sizeLimitedOutput) WriteRune(r rune) (int, error) {
+	n := utf8.RuneLen(r)
+	if n < 0 {
+		// Written as utf8.RuneError.
+		n = utf8.UTFMax - 1
+	}
+	if !o.fits(n) {
+		return 0, nil
+	}
+	return o.out.WriteRune(r)
+}
+
+// fits counts n more bytes, if they fit.
+func (o *sizeLimitedOutput) fits(n int) bool {
+	state := o.state
+	if state.aborted {
+		return false
+	}
+	if state.size+n > state.maxSize {
+		state.aborted = true
+		state.fail(generatorError(ErrOutputTooLarge, "generated %d bytes, %d more would exceed MaxOutputSize %d",
+			state.size, n, state.maxSize))
+		return false
+	}
+	state.size += n
+	return true
+}
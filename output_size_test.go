@@ -0,0 +1,90 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMaxOutputSize(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Aborts nested unbounded repeats", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(a{0,}){0,}`, &GeneratorArgs{
+			MinUnboundedRepeatCount: 1000,
+			MaxUnboundedRepeatCount: 1000,
+			MaxOutputSize:           5000,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := generator.GenerateE()
+		if !errors.Is(err, ErrOutputTooLarge) {
+			t.Fatalf("should be ErrOutputTooLarge, was %v", err)
+		}
+		if s != "" {
+			t.Fatalf("should not return a string, returned %d bytes", len(s))
+		}
+		if !strings.Contains(err.Error(), "generated 5000 bytes") {
+			t.Fatalf("%q should contain the size reached", err)
+		}
+
+		if s := generator.Generate(); len(s) != 5000 {
+			t.Fatal("should return the groups generated so far:", len(s))
+		}
+	})
+
+	t.Run("Counts runes in bytes", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`éé(é)`, &GeneratorArgs{MaxOutputSize: 5})
+		if _, err := generator.GenerateE(); !errors.Is(err, ErrOutputTooLarge) {
+			t.Fatalf("should be ErrOutputTooLarge, was %v", err)
+		}
+		generator, _ = NewGenerator(`éé(é)`, &GeneratorArgs{MaxOutputSize: 6})
+		if s, err := generator.GenerateE(); err != nil || s != "ééé" {
+			t.Fatal("should be equal:", s, "ééé", err)
+		}
+	})
+
+	t.Run("Strings within the limit are unaffected", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{MaxOutputSize: 10, MaxUnboundedRepeatCount: 10}
+		generator, _ := NewGenerator(`(a*)b{0,9}`, args)
+		for i := 0; i < SampleSize; i++ {
+			s, err := generator.GenerateE()
+			if err != nil && !errors.Is(err, ErrOutputTooLarge) {
+				t.Fatal(err)
+			}
+			if err == nil && len(s) > 10 {
+				t.Fatalf("%q is longer than 10 bytes", s)
+			}
+		}
+
+		GeneratesStringMatching(t, &GeneratorArgs{MaxOutputSize: 3}, `abc`, `^abc$`)
+
+		if _, err := NewGenerator(`a`, &GeneratorArgs{MaxOutputSize: -1}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
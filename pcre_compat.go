@@ -0,0 +1,171 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Conditions referring to a capture group: (?(1)...), (?(<name>)...), (?('name')...) and (?(name)...).
+var groupCondition = regexp.MustCompile(`^(\d+|<\w+>|'\w+'|\w+)$`)
+
+// Conditions with a special meaning in PCRE, which look like group names.
+var specialCondition = regexp.MustCompile(`^(R\d*|R&\w+|DEFINE)$`)
+
+// rewriteConditionals rewrites the PCRE conditional groups in pattern to alternations of their branches.
+func rewriteConditionals(pattern string) (string, error) {
+	var result strings.Builder
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], `\Q`):
+			end := quoteEnd(pattern, i)
+			result.WriteString(pattern[i:end])
+			i = end
+		case pattern[i] == '\\':
+			// Copy escapes as is, so escaped parentheses aren't taken for groups.
+			end := i + 2
+			if end > len(pattern) {
+				end = len(pattern)
+			}
+			result.WriteString(pattern[i:end])
+			i = end
+		case pattern[i] == '[':
+			end := classEnd(pattern, i)
+			result.WriteString(pattern[i:end])
+			i = end
+		case strings.HasPrefix(pattern[i:], "(?("):
+			rewritten, end, err := rewriteConditional(pattern, i)
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(rewritten)
+			i = end
+		default:
+			result.WriteByte(pattern[i])
+			i++
+		}
+	}
+	return result.String(), nil
+}
+
+// rewriteConditional rewrites the conditional group starting at start, returning the rewritten group and the
+// index in pattern just after it.
+func rewriteConditional(pattern string, start int) (string, int, error) {
+	conditionStart := start + len("(?(")
+	conditionLength := strings.IndexByte(pattern[conditionStart:], ')')
+	if conditionLength < 0 {
+		return "", 0, generatorError(nil, "unterminated condition at offset %d in /%s/", start, pattern)
+	}
+	condition := pattern[conditionStart : conditionStart+conditionLength]
+	if !groupCondition.MatchString(condition) || specialCondition.MatchString(condition) {
+		return "", 0, generatorError(nil, "unsupported condition (?(%s)...) in /%s/: only group references can be rewritten",
+			condition, pattern)
+	}
+
+	// Find the end of the group, and the | separating the branches.
+	bodyStart := conditionStart + conditionLength + 1
+	var separators []int
+	depth := 0
+	for i := bodyStart; i < len(pattern); {
+		switch pattern[i] {
+		case '\\':
+			if strings.HasPrefix(pattern[i:], `\Q`) {
+				i = quoteEnd(pattern, i)
+			} else {
+				i += 2
+			}
+			continue
+		case '[':
+			i = classEnd(pattern, i)
+			continue
+		case '(':
+			depth++
+		case '|':
+			if depth == 0 {
+				separators = append(separators, i)
+			}
+		case ')':
+			if depth > 0 {
+				depth--
+				break
+			}
+			if len(separators) > 1 {
+				return "", 0, generatorError(nil, "conditional group at offset %d in /%s/ has more than 2 branches",
+					start, pattern)
+			}
+
+			yes, no := pattern[bodyStart:i], ""
+			if len(separators) == 1 {
+				yes, no = pattern[bodyStart:separators[0]], pattern[separators[0]+1:i]
+			}
+			// Branches may contain conditionals themselves.
+			yes, err := rewriteConditionals(yes)
+			if err != nil {
+				return "", 0, err
+			}
+			no, err = rewriteConditionals(no)
+			if err != nil {
+				return "", 0, err
+			}
+			return "(?:" + yes + "|" + no + ")", i + 1, nil
+		}
+		i++
+	}
+	return "", 0, generatorError(nil, "unterminated conditional group at offset %d in /%s/", start, pattern)
+}
+
+// quoteEnd returns the index just after the quoted text starting with `\Q` at start, which ends with `\E` or at the
+// end of pattern.
+func quoteEnd(pattern string, start int) int {
+	end := strings.Index(pattern[start:], `\E`)
+	if end < 0 {
+		return len(pattern)
+	}
+	return start + end + len(`\E`)
+}
+
+// classEnd returns the index just after the character class starting at start, or the end of pattern if the class
+// is not terminated.
+func classEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '^' {
+		i++
+	}
+	// A ] right after the opening bracket is a literal.
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for i < len(pattern) {
+		switch {
+		case pattern[i] == '\\':
+			i += 2
+		case strings.HasPrefix(pattern[i:], "[:"):
+			if end := strings.Index(pattern[i:], ":]"); end >= 0 {
+				i += end + len(":]")
+			} else {
+				i++
+			}
+		case pattern[i] == ']':
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(pattern)
+}
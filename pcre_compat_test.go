@@ -0,0 +1,90 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestPCRECompat(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Rewrites conditionals", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			pattern  string
+			expected string
+		}{
+			{`(x)?(?(1)a|b)`, `(x)?(?:a|b)`},
+			{`(?P<q>")?\w+(?(<q>)")`, `(?P<q>")?\w+(?:"|)`},
+			{`(a)(?('a')(b|c)|[|)])`, `(a)(?:(b|c)|[|)])`},
+			{`(a)(?(1)(?(2)x|y)|\))`, `(a)(?:(?:x|y)|\))`},
+			{`\(?(a)`, `\(?(a)`},
+			{`(a)\Q(?(1)b)\E(?(1)c)`, `(a)\Q(?(1)b)\E(?:c|)`},
+			{`(a)?(?(1)\Q)|\E|b)`, `(a)?(?:\Q)|\E|b)`},
+		} {
+			rewritten, err := rewriteConditionals(tc.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rewritten != tc.expected {
+				t.Fatalf("/%s/ should be rewritten to /%s/, was /%s/", tc.pattern, tc.expected, rewritten)
+			}
+		}
+	})
+
+	t.Run("Generates either branch", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Flags: syntax.Perl, PCRECompat: true}
+		GeneratesStringMatching(t, args, `<(x)?(?(1)a|b)>`, `^<x?[ab]>$`)
+		GeneratesStringMatching(t, args, `(a)?(?(1)\Q)|\E|b)`, `^a?(\)\||b)$`)
+
+		generator, err := NewGenerator(`(?(1)a|b)`, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := map[string]bool{}
+		for i := 0; i < SampleSize; i++ {
+			seen[generator.Generate()] = true
+		}
+		if !seen["a"] || !seen["b"] {
+			t.Fatalf("should generate both branches, generated %v", seen)
+		}
+	})
+
+	t.Run("Rejects complex conditionals", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`(?(?=a)a|b)`, `(?(R)a|b)`, `(?(DEFINE)a)`, `(?(1)a|b|c)`, `(?(1)a`, `(?(1`} {
+			if _, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, PCRECompat: true}); err == nil {
+				t.Fatalf("/%s/ should be rejected", pattern)
+			}
+		}
+	})
+
+	t.Run("Off by default", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewGenerator(`(?(1)a|b)`, &GeneratorArgs{Flags: syntax.Perl}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+)
+
+// placeholders maps each placeholder token recognized in literals to the function generating its replacement.
+var placeholders = map[string]func(rng *rand.Rand) string{
+	"{uuid}": generateUUID,
+}
+
+// createPlaceholderGenerator returns a generator for a literal that replaces the placeholders in it,
+// or nil if it contains none.
+func createPlaceholderGenerator(regexp *syntax.Regexp) *internalGenerator {
+	literal := string(regexp.Rune)
+
+	// Split the literal into text and placeholder segments.
+	var segments []func(state *generatorState)
+	for len(literal) > 0 {
+		start, token := -1, ""
+		for t := range placeholders {
+			if i := strings.Index(literal, t); i >= 0 && (start < 0 || i < start) {
+				start, token = i, t
+			}
+		}
+		if start < 0 {
+			break
+		}
+
+		text, replace := literal[:start], placeholders[token]
+		segments = append(segments,
+			func(state *generatorState) { state.out.WriteString(text) },
+			func(state *generatorState) { state.out.WriteString(replace(state.rng)) })
+		literal = literal[start+len(token):]
+	}
+	if segments == nil {
+		return nil
+	}
+	segments = append(segments, func(state *generatorState) { state.out.WriteString(literal) })
+
+	return &internalGenerator{Name: regexp.String(), GenerateFunc: func(state *generatorState) {
+		for _, segment := range segments {
+			segment(state)
+		}
+	}}
+}
+
+// generateUUID returns a random RFC 4122 version 4 UUID.
+func generateUUID(rng *rand.Rand) string {
+	var b [16]byte
+	for i := 0; i < len(b); i += 8 {
+		v := rng.Uint64()
+		for j := 0; j < 8; j++ {
+			b[i+j] = byte(v >> (8 * j))
+		}
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4.
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant.
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+)
+
+func TestPlaceholders(t *testing.T) {
+	t.Parallel()
+
+	t.Run("uuid is replaced by a version 4 UUID", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Placeholders: true}
+		GeneratesStringMatching(t, args, `request {uuid} failed( after {uuid})?`,
+			`^request [0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12} failed`+
+				`( after [0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12})?$`)
+	})
+
+	t.Run("Is reproducible with a fixed seed", func(t *testing.T) {
+		t.Parallel()
+
+		gen1, _ := NewGenerator(`id={uuid}`, &GeneratorArgs{Placeholders: true, RngSource: rand.NewSource(1)})
+		gen2, _ := NewGenerator(`id={uuid}`, &GeneratorArgs{Placeholders: true, RngSource: rand.NewSource(1)})
+		s1, s2 := gen1.Generate(), gen2.Generate()
+		if s1 != s2 {
+			t.Fatalf("%q and %q should be equal", s1, s2)
+		}
+		if s1 == gen1.Generate() {
+			t.Fatal("each call should generate a new UUID")
+		}
+	})
+
+	t.Run("Is literal when disabled", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`id={uuid}`, nil)
+		if s := generator.Generate(); s != "id={uuid}" {
+			t.Fatalf("wrong output: %q", s)
+		}
+		if matched, _ := regexp.MatchString(`\{uuid\}`, generator.Generate()); !matched {
+			t.Fatal("should match")
+		}
+	})
+}
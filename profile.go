@@ -0,0 +1,72 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+/*
+Profile is a bundle of GeneratorArgs settings for a common use case. A profile only sets fields that are left at
+their zero value, so any field set explicitly takes precedence over it.
+
+There is no profile for binary data: generators produce strings of runes, and have no setting to emit arbitrary
+bytes.
+*/
+type Profile int
+
+const (
+	// NoProfile changes no settings. This is the default.
+	NoProfile Profile = iota
+
+	/*
+		ProfileReadableASCII generates short, human-readable strings. It sets:
+			MaxUnboundedRepeatCount: 16, so "x*" and "x+" don't produce long runs
+			UTF8ByteLengthMix: {1, 0, 0, 0}, so classes like "." generate ASCII when they can
+			WhitespacePolicy: SingleSpace
+			TrimSurroundingDotStar: true
+	*/
+	ProfileReadableASCII
+
+	/*
+		ProfileI18n generates strings exercising non-ASCII text handling. It sets:
+			UTF8ByteLengthMix: {1, 1, 1, 1}, so classes like "." generate runes of every UTF-8 length equally
+			often, instead of mostly 3 and 4 byte ones
+	*/
+	ProfileI18n
+)
+
+func (profile Profile) apply(args *GeneratorArgs) error {
+	switch profile {
+	case NoProfile:
+	case ProfileReadableASCII:
+		if args.MaxUnboundedRepeatCount == 0 {
+			args.MaxUnboundedRepeatCount = 16
+		}
+		if args.UTF8ByteLengthMix == ([4]float64{}) {
+			args.UTF8ByteLengthMix = [4]float64{1, 0, 0, 0}
+		}
+		if args.WhitespacePolicy == AsIs {
+			args.WhitespacePolicy = SingleSpace
+		}
+		args.TrimSurroundingDotStar = true
+	case ProfileI18n:
+		if args.UTF8ByteLengthMix == ([4]float64{}) {
+			args.UTF8ByteLengthMix = [4]float64{1, 1, 1, 1}
+		}
+	default:
+		return generatorError(nil, "invalid Profile: %d", profile)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Applies settings", func(t *testing.T) {
+		t.Parallel()
+
+		args := GeneratorArgs{Profile: ProfileReadableASCII}
+		if err := args.initialize(); err != nil {
+			t.Fatal(err)
+		}
+		if args.MaxUnboundedRepeatCount != 16 || args.UTF8ByteLengthMix != [4]float64{1, 0, 0, 0} ||
+			args.WhitespacePolicy != SingleSpace || !args.TrimSurroundingDotStar {
+			t.Fatalf("should apply ProfileReadableASCII, was %+v", args)
+		}
+
+		args = GeneratorArgs{Profile: ProfileI18n}
+		if err := args.initialize(); err != nil {
+			t.Fatal(err)
+		}
+		if args.UTF8ByteLengthMix != [4]float64{1, 1, 1, 1} || args.MaxUnboundedRepeatCount != DefaultMaxUnboundedRepeatCount {
+			t.Fatalf("should apply ProfileI18n, was %+v", args)
+		}
+	})
+
+	t.Run("Explicit settings take precedence", func(t *testing.T) {
+		t.Parallel()
+
+		args := GeneratorArgs{
+			Profile:                 ProfileReadableASCII,
+			MaxUnboundedRepeatCount: 100,
+			WhitespacePolicy:        NoLeadingTrailing,
+		}
+		if err := args.initialize(); err != nil {
+			t.Fatal(err)
+		}
+		if args.MaxUnboundedRepeatCount != 100 || args.WhitespacePolicy != NoLeadingTrailing {
+			t.Fatalf("should keep explicit settings, was %+v", args)
+		}
+		if args.UTF8ByteLengthMix != [4]float64{1, 0, 0, 0} {
+			t.Fatal("should apply the profile to other settings")
+		}
+	})
+
+	t.Run("Readable output", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{Flags: syntax.Perl, Profile: ProfileReadableASCII},
+			`.*ERROR.*`, `^[\x01-\x7f]{0,8}ERROR[\x01-\x7f]{0,8}$`)
+	})
+
+	t.Run("Rejects invalid profiles", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewGenerator(`a`, &GeneratorArgs{Profile: Profile(42)}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math/rand"
+	"reflect"
+)
+
+// GenerateRand is like Generate, but draws random numbers from rng instead of the generator's source.
+func (gen *internalGenerator) GenerateRand(rng *rand.Rand) string {
+	state := gen.newState()
+	state.rng = rng
+	return gen.generateString(state)
+}
+
+/*
+QuickValues returns a function to set as testing/quick's Config.Values for checking f, which fills its string
+arguments with strings generated by generators, one for each argument in order, or a single one for all of them.
+It returns an error if f isn't a function taking only strings, or if there is neither a single generator nor one
+for each of its arguments. Random numbers are drawn from quick's source, so failures are reproducible by setting
+Config.Rand.
+*/
+func QuickValues(f interface{}, generators ...Generator) (func(values []reflect.Value, rng *rand.Rand), error) {
+	fType := reflect.TypeOf(f)
+	if fType == nil || fType.Kind() != reflect.Func {
+		return nil, generatorError(nil, "QuickValues needs the function to check, got %T", f)
+	}
+	for i := 0; i < fType.NumIn(); i++ {
+		if fType.In(i).Kind() != reflect.String {
+			return nil, generatorError(nil, "argument %d of the function to check is a %s, not a string", i, fType.In(i))
+		}
+	}
+	if len(generators) != 1 && len(generators) != fType.NumIn() {
+		return nil, generatorError(nil, "QuickValues needs 1 generator or %d, one for each argument, got %d",
+			fType.NumIn(), len(generators))
+	}
+
+	return func(values []reflect.Value, rng *rand.Rand) {
+		for i := range values {
+			generator := generators[0]
+			if len(generators) > 1 {
+				generator = generators[i]
+			}
+			values[i] = reflect.ValueOf(generator.GenerateRand(rng)).Convert(fType.In(i))
+		}
+	}, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func ExampleQuickValues() {
+	generator, _ := NewGenerator(`[1-9]\d{0,8}`, &GeneratorArgs{Flags: syntax.Perl})
+
+	roundTrips := func(s string) bool {
+		n, err := strconv.Atoi(s)
+		return err == nil && strconv.Itoa(n) == s
+	}
+	values, _ := QuickValues(roundTrips, generator)
+	err := quick.Check(roundTrips, &quick.Config{Values: values})
+	fmt.Println(err)
+	// Output:
+	// <nil>
+}
+
+func TestQuickValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Draws from quick's source", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`[a-z]{5,20}`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		collect := func(seed int64) []string {
+			var seen []string
+			record := func(s string) bool {
+				seen = append(seen, s)
+				return true
+			}
+			values, err := QuickValues(record, generator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := &quick.Config{MaxCount: 20, Rand: rand.New(rand.NewSource(seed)), Values: values}
+			if err := quick.Check(record, config); err != nil {
+				t.Fatal(err)
+			}
+			return seen
+		}
+
+		if a, b := collect(1), collect(1); strings.Join(a, ",") != strings.Join(b, ",") {
+			t.Fatal("should be equal:", a, b)
+		}
+	})
+
+	t.Run("Uses a generator for each argument", func(t *testing.T) {
+		t.Parallel()
+
+		digits, err := NewGenerator(`[0-9]{3}`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		letters, err := NewGenerator(`[a-z]{3}`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := func(d, l string) bool {
+			_, err := strconv.Atoi(d)
+			return err == nil && strings.Trim(l, "abcdefghijklmnopqrstuvwxyz") == ""
+		}
+		values, err := QuickValues(check, digits, letters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := quick.Check(check, &quick.Config{Values: values}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Rejects mismatched arguments", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`a`, nil)
+		two := func(a, b string) bool { return true }
+		for name, call := range map[string]func() error{
+			"no generators": func() error { _, err := QuickValues(two); return err },
+			"too few": func() error {
+				_, err := QuickValues(func(a, b, c string) bool { return true }, generator, generator)
+				return err
+			},
+			"too many":        func() error { _, err := QuickValues(two, generator, generator, generator); return err },
+			"not a function":  func() error { _, err := QuickValues("f", generator); return err },
+			"non-string args": func() error { _, err := QuickValues(func(int) bool { return true }, generator); return err },
+		} {
+			if call() == nil {
+				t.Fatalf("%s: err should not be nil", name)
+			}
+		}
+	})
+}
@@ -3,11 +3,25 @@ package regen
 import (
 	crand "crypto/rand"
 	"encoding/binary"
+	"hash/fnv"
 	"log"
-	mrand "math/rand"
+	"math/rand"
+	"sync"
 )
 
-var rand = mrand.New(cryptoSource{})
+// defaultRng is used by generators created without an RngSource.
+var defaultRng = rand.New(cryptoSource{})
+
+/*
+CryptoRngSource returns a source backed by crypto/rand, for generating strings that must be unpredictable, e.g.
+identifiers or secrets. Generators use it directly instead of seeding their own source from it, like generators
+without an RngSource, so their strings are not reproducible and Salt and SnapshotState have no effect. (Strings
+returned with a token are the exception: they are generated from a source seeded from it, so that the token
+reproduces them.) It is safe for concurrent use.
+*/
+func CryptoRngSource() rand.Source {
+	return cryptoSource{}
+}
 
 type cryptoSource struct{}
 
@@ -24,3 +38,106 @@ func (s cryptoSource) Uint64() (v uint64) {
 	}
 	return v
 }
+
+// saltSeed mixes salt into seed. An empty salt leaves the seed unchanged.
+func saltSeed(seed int64, salt []byte) int64 {
+	if len(salt) == 0 {
+		return seed
+	}
+	h := fnv.New64a()
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(seed))
+	h.Write(b[:])
+	h.Write(salt)
+	return int64(h.Sum64())
+}
+
+// xorShift64Source is a xorshift64* source (http://vigna.di.unimi.it/ftp/papers/xorshift.pdf).
+// It is cheap to seed, and its whole state is a single word.
+type xorShift64Source struct {
+	state uint64
+}
+
+func newXorShift64Source(seed int64) *xorShift64Source {
+	s := &xorShift64Source{}
+	s.Seed(seed)
+	return s
+}
+
+func (s *xorShift64Source) Seed(seed int64) {
+	// Scramble the seed with splitmix64 so that similar seeds give unrelated sequences.
+	z := uint64(seed) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+
+	// The state must never be 0.
+	if z == 0 {
+		z = 0x9e3779b97f4a7c15
+	}
+	s.state = z
+}
+
+func (s *xorShift64Source) Uint64() uint64 {
+	x := s.state
+	x ^= x >> 12
+	x ^= x << 25
+	x ^= x >> 27
+	s.state = x
+	return x * 2685821657736338717
+}
+
+func (s *xorShift64Source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// savableSource is a source whose state can be saved and restored.
+type savableSource interface {
+	rand.Source64
+	getState() uint64
+	setState(state uint64)
+}
+
+func (s *xorShift64Source) getState() uint64 {
+	return s.state
+}
+
+func (s *xorShift64Source) setState(state uint64) {
+	s.state = state
+}
+
+// lockedSource makes src safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src savableSource
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	s.src.Seed(seed)
+	s.mu.Unlock()
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) getState() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.getState()
+}
+
+func (s *lockedSource) setState(state uint64) {
+	s.mu.Lock()
+	s.src.setState(state)
+	s.mu.Unlock()
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math/rand"
+	randv2 "math/rand/v2"
+)
+
+/*
+RandV2Source adapts a math/rand/v2 source for use as RngSource, e.g.
+
+	gen, err := NewGenerator(`[a-z]+`, &GeneratorArgs{RngSource: RandV2Source(randv2.NewPCG(1, 2))})
+
+A *rand/v2.Rand is a source too, so it can be passed as it is. Like any RngSource, it is only used to seed the
+generator's own source. The adapted source cannot be reseeded: Seed does nothing.
+*/
+func RandV2Source(src randv2.Source) rand.Source {
+	return randV2Source{src: src}
+}
+
+type randV2Source struct {
+	src randv2.Source
+}
+
+func (s randV2Source) Seed(seed int64) {}
+
+func (s randV2Source) Int63() int64 {
+	return int64(s.src.Uint64() & ^uint64(1<<63))
+}
+
+func (s randV2Source) Uint64() uint64 {
+	return s.src.Uint64()
+}
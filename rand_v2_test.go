@@ -0,0 +1,54 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	randv2 "math/rand/v2"
+	"testing"
+)
+
+func TestRandV2Source(t *testing.T) {
+	t.Parallel()
+
+	generate := func(src randv2.Source) string {
+		gen, err := NewGenerator(`[a-z]{10}`, &GeneratorArgs{RngSource: RandV2Source(src)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return gen.Generate()
+	}
+
+	t.Run("Reproducible from PCG", func(t *testing.T) {
+		if s1, s2 := generate(randv2.NewPCG(1, 2)), generate(randv2.NewPCG(1, 2)); s1 != s2 {
+			t.Fatalf("same seed generated %q and %q", s1, s2)
+		}
+	})
+
+	t.Run("Accepts Rand", func(t *testing.T) {
+		s1 := generate(randv2.New(randv2.NewPCG(1, 2)))
+		if s2 := generate(randv2.NewPCG(1, 2)); s1 != s2 {
+			t.Fatalf("Rand generated %q, its source %q", s1, s2)
+		}
+	})
+
+	t.Run("Different seeds", func(t *testing.T) {
+		if s1, s2 := generate(randv2.NewPCG(1, 2)), generate(randv2.NewPCG(3, 4)); s1 == s2 {
+			t.Fatalf("different seeds both generated %q", s1)
+		}
+	})
+}
@@ -35,6 +35,26 @@ Constraints
 If you care about the maximum number, specify it explicitly in the expression,
 e.g. "x{0,256}".
 
+Assertions ("^", "$", "\A", "\z", "\b" and "\B") don't generate anything, but generated strings satisfy them:
+characters next to them are restricted where possible (e.g. the "." in `a\b.` never generates a word character),
+and strings that still don't match are regenerated. NewGenerator returns ErrUnsatisfiable for expressions whose
+assertions can never hold, e.g. "a$b" or `a\bb`. Strings are not checked if other settings make them not match
+the expression anyway, e.g. a CaptureGroupHandler, in which case assertions inside the expression may not hold.
+A word boundary at the start or end of an expression that can't hold at the start or end of a string, e.g. in
+`\Bfoo` or `-\b`, is satisfied by generating a word character before or after the match: such strings contain a
+match, e.g. "xfoo", rather than matching as a whole.
+
+"\A" and "\z" always match at the start and end of the string, even under syntax.Multiline. "\Z", which Go's parser
+rejects, is supported with syntax.PerlX: it matches at the end of the string or before a newline ending it, so
+`a\Z` generates "a" or "a\n".
+
+Backreferences (`\1` to `\9`) are supported, although Go's parser rejects them: they generate the last value
+generated for their group, e.g. `(\w+)=\1` generates "ab=ab". Like in Go's parser, `\1` to `\7` followed by an octal
+digit are octal escapes instead, e.g. `\101` generates "A". Control escapes (e.g. `\cK` for "\v"), which Go's
+parser rejects, are supported with syntax.PerlX.
+
+Text quoted with `\Q` and `\E` is generated as it is, e.g. `\Qa.b*\E` generates "a.b*", with syntax.PerlX.
+
 Flags
 
 Flags can be passed to the parser by setting them in the GeneratorArgs struct.
@@ -43,26 +63,29 @@ matching them are set.
 
 E.g.
 Generate(".|[^a]") will never generate newlines. To generate newlines, create a generator and pass
-the flag syntax.MatchNL.
+the flag syntax.MatchNL. syntax.DotNL, or the s flag in the expression (e.g. `(?s).`), only lets "." generate them,
+as it only lets "." match them.
 
 The Perl character class flag is supported, and required if the pattern contains them.
 
-Unicode groups are not supported at this time. Support may be added in the future.
+Unicode classes (e.g. `\p{L}`, `\p{Greek}` or `\PN`) are supported with the syntax.UnicodeGroups flag, which
+syntax.Perl includes: they generate runes of the Unicode category or script, or of its complement if negated.
 
 Concurrent Use
 
-A generator can safely be used from multiple goroutines without locking.
+Generators without an RngSource draw from the default source, which is safe for concurrent use, so they can be
+used from multiple goroutines without locking.
 
 A large bottleneck with running generators concurrently is actually the entropy source. Sources returned from
 rand.NewSource() are slow to seed, and not safe for concurrent use. Instead, the source passed in GeneratorArgs
 is used to seed an XorShift64 source (algorithm from the paper at http://vigna.di.unimi.it/ftp/papers/xorshift.pdf).
 This source only uses a single variable internally, and is much faster to seed than the default source. One
-source is created per call to NewGenerator. If no source is passed in, the default source is used to seed.
+source is created per call to NewGenerator.
 
-The source is not locked and does not use atomic operations, so there is a chance that multiple goroutines using
-the same source may get the same output. While obviously not cryptographically secure, I think the simplicity and performance
-benefit outweighs the risk of collisions. If you really care about preventing this, the solution is simple: don't
-call a single Generator from multiple goroutines.
+That source is not locked, so a generator with an RngSource must not be called from multiple goroutines at once,
+unless GeneratorArgs.Concurrent is set. Concurrent locks the source for every random number drawn, which makes the
+generator safe to share, at the cost of contention between goroutines. Concurrent calls are then only reproducible
+with a seed if their order is. Alternatively, give each goroutine its own generator, e.g. with Clone.
 
 Benchmarks
 
@@ -94,13 +117,28 @@ the results of running the benchmarks with minimal load are:
 package regen
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"hash"
+	"io"
+	"math"
+	"math/big"
+	"math/rand"
+	"regexp"
 	"regexp/syntax"
+	"strconv"
+	"unicode/utf8"
 )
 
 // DefaultMaxUnboundedRepeatCount is default value for MaxUnboundedRepeatCount.
 const DefaultMaxUnboundedRepeatCount = 4096
 
+// DefaultSurroundingDotStarMax is default value for SurroundingDotStarMax.
+const DefaultSurroundingDotStarMax = 8
+
+// DefaultMaxDepth is default value for MaxDepth.
+const DefaultMaxDepth = 1000
+
 // CaptureGroupHandler is a function that is called for each capture group in a regular expression.
 // index and name are the index and name of the group. If unnamed, name is empty. The first capture group has index 0
 // (not 1, as when matching).
@@ -109,50 +147,642 @@ const DefaultMaxUnboundedRepeatCount = 4096
 // args is the args used to create the generator calling this function.
 type CaptureGroupHandler func(index int, name string, group *syntax.Regexp, generator Generator, args *GeneratorArgs) string
 
+// CaptureGroupHandlerE is like CaptureGroupHandler, but can fail, e.g. when the data source it draws values from
+// is empty. An error aborts generating the string: GenerateE returns it, and Generate returns what was generated
+// before it.
+type CaptureGroupHandlerE func(index int, name string, group *syntax.Regexp, generator Generator,
+	args *GeneratorArgs) (string, error)
+
+// CaptureGroupContext is what a CaptureGroupContextHandler is called with for a capture group: the arguments of a
+// CaptureGroupHandler, and the values generated for the other groups of the string being generated.
+type CaptureGroupContext struct {
+	Index     int
+	Name      string
+	Group     *syntax.Regexp
+	Generator Generator
+	Args      *GeneratorArgs
+
+	// Values generated so far for the groups of the string, keyed by index. Groups are generated from left to right,
+	// so every group before this one that was generated is in it, with its last value if it was repeated.
+	Values map[int]string
+	// The values of named groups in Values, keyed by name.
+	NamedValues map[string]string
+}
+
+// CaptureGroupContextHandler is like CaptureGroupHandler, but can use the values of the groups generated before,
+// e.g. to generate a record consistent with its first field.
+type CaptureGroupContextHandler func(group *CaptureGroupContext) string
+
 // GeneratorArgs are arguments passed to NewGenerator that control how generators
 // are created.
 type GeneratorArgs struct {
+	// May be nil, in which case a cryptographically secure source is used.
+	// Otherwise, it is only used to seed the generator's own source (see Concurrent Use in the package documentation),
+	// so generators created with sources seeded identically generate the same strings.
+	// Set it to CryptoRngSource() for strings that must be unpredictable, e.g. secrets.
+	// Use RandV2Source to pass a math/rand/v2 source.
+	RngSource rand.Source
+
+	// Used directly to draw random numbers, instead of a source of the generator's own, so that its algorithm and
+	// locking apply, e.g. to share it across an application. Must not be set together with RngSource or Seed.
+	// Like CryptoRngSource, Salt, SnapshotState and Clone seeding have no effect with it, and Concurrent does not
+	// lock it: it must be safe for concurrent use itself to use the generator from several goroutines.
+	Rand *rand.Rand
+
+	// Shorthand for an RngSource of rand.NewSource(*Seed). Must not be set together with RngSource.
+	Seed *int64
+
+	// Mixed into the seed drawn from RngSource, so that generators with identically seeded sources but different
+	// salts (e.g. one per tenant) generate different, individually reproducible strings.
+	// Has no effect without an RngSource.
+	Salt []byte
+
 	// Default is 0 (syntax.POSIX).
 	Flags syntax.Flags
 
+	// Bundle of settings for a common use case, see Profile. Fields set explicitly take precedence over it.
+	Profile Profile
+
 	// Maximum number of instances to generate for unbounded repeat expressions (e.g. ".*" and "{1,}")
 	// Default is DefaultMaxUnboundedRepeatCount.
 	MaxUnboundedRepeatCount uint
 	// Minimum number of instances to generate for unbounded repeat expressions (e.g. ".*")
 	// Default is 0.
 	MinUnboundedRepeatCount uint
+	// Maximum number of instances to generate for "*", "+" and open ranges like "{3,}" respectively, to size them
+	// independently, e.g. a short ".*" and a long "\d{3,}". Default is 0, which means MaxUnboundedRepeatCount.
+	// Open ranges with a larger minimum, e.g. "a{5,}" with OpenRangeMax 3, generate exactly their minimum.
+	StarMax      uint
+	PlusMax      uint
+	OpenRangeMax uint
+
+	// Set this to pick the number of instances to generate for every repeat expression, bounded (e.g. "{2,8}" and
+	// "?") or not, e.g. GeometricRepeat(0.3) to favor short runs. Unbounded ones are bounded as configured above.
+	// Takes precedence over Uniform. The zero value picks counts with UniformRepeat, except for bounded ranges
+	// like "{2,8}", which generate each instance past the minimum with probability 1/2 if the one before it was.
+	RepeatCountSampler RepeatCountSampler
+
+	// Runes to generate for "." instead of every rune, e.g. []rune("abcdef0123456789") to generate hex digits.
+	// Newline is only generated from them if "." matches it. Explicit character classes are unaffected.
+	AnyCharRunes []rune
+	// Set this to only generate ASCII runes from "." and character classes, e.g. `[^a]` and `\W`. Classes without
+	// ASCII runes, e.g. `[é]`, make NewGenerator return an error. Literals are unaffected.
+	ASCIIOnly bool
+	// Set this to only generate runes unicode.IsPrint reports as printable, including space, from "." and
+	// character classes, e.g. to keep control characters out of logs. Classes without printable runes, e.g.
+	// `[[:cntrl:]]`, make NewGenerator return an error. Literals are unaffected.
+	PrintableOnly bool
+	// Runes never to generate, e.g. []rune(",\"\n") to keep output safe to put in a CSV field. They are removed
+	// from ".", character classes and literals: optional parts and alternation branches left with nothing to
+	// generate are skipped, and NewGenerator returns an error naming the first required one, e.g. `[,;]`.
+	ExcludeRunes []rune
 
 	// Set this to perform special processing of capture groups (e.g. `(\w+)`). The zero value will generate strings
 	// from the expressions in the group.
 	CaptureGroupHandler CaptureGroupHandler
+	// Set this instead of CaptureGroupHandler to also pass handlers the values generated for groups before.
+	CaptureGroupContextHandler CaptureGroupContextHandler
+	// Set this instead of CaptureGroupHandler for a handler that can fail.
+	CaptureGroupHandlerE CaptureGroupHandlerE
+
+	// Probability, between 0 and 1, that an optional capture group (e.g. `(\w+)?`) is generated, keyed by the
+	// index of the group (0-based, as passed to CaptureGroupHandler). Optional groups not listed are generated
+	// half of the time.
+	OptionalProbabilities map[int]float64
+
+	// Set this to make every string the generator can generate about equally likely, instead of e.g. every
+	// alternation branch: branches and numbers of repetitions are chosen in proportion to the number of strings
+	// they generate, as counted by NumMatches. So `a|bb?` generates "a" a third of the time rather than half.
+	// Unbounded repeat expressions are still generated up to MaxUnboundedRepeatCount times, which bounds the
+	// length of the strings sampled from, and nearly always are for expressions with several strings to repeat.
+	Uniform bool
+
+	// Relative weights of the branches of alternations (e.g. `common|rare`), keyed by the alternation as
+	// syntax.Regexp.String() prints it, with a weight for each branch in order. E.g. {"common|rare": {9, 1}}
+	// generates "common" 90% of the time. Alternations not listed choose every branch equally likely, or as set by
+	// Uniform. The parser simplifies some alternations, e.g. `a|b` to `[a-b]` and `ab|ac` to `a(?:b|c)`, so
+	// NewGenerator returns an error listing the alternations there are if a key isn't one of them.
+	AlternationWeights map[string][]float64
+
+	// Set this to generate the same string every time without drawing any random numbers, e.g. for golden files:
+	// the first alternation branch that can match, the minimum number of instances of repeat expressions, every
+	// optional expression (e.g. `a?`, but not `a{0,1}`), and the lowest graphic rune other than space of character
+	// classes, or their lowest rune if they have none. Case-insensitive literals are generated as the parser stores
+	// them, e.g. "HELLO" for `(?i)hello`, and group value pools are cycled through in order. Takes precedence over
+	// the settings picking these at random, e.g. Uniform and RepeatCountSampler. Placeholders are still replaced
+	// with random values.
+	Deterministic bool
+
+	// Values for named capture groups (e.g. `(?P<region>\w+)`), keyed by group name. Each group in the map emits
+	// the values of its pool in order, cycling back to the first after the last, instead of generating from its
+	// expression. The CaptureGroupHandler is not called for these groups, and values are not checked against
+	// the group's expression. Each generator cycles independently, and a group repeated in the expression
+	// (e.g. `(?P<region>\w+)+`) advances once per repetition.
+	GroupValuePools map[string][]string
+	// Set this to draw values from GroupValuePools at random instead of in order.
+	RandomGroupValuePools bool
+	// Fixed values for named capture groups, keyed by group name, e.g. {"id": "42"} to always generate "42" for
+	// `(?P<id>\d+)`. Like GroupValuePools, which they take precedence over, the CaptureGroupHandler is not called
+	// for these groups, and values are not checked against the group's expression, so they may deliberately not
+	// match it.
+	FixedGroups map[string]string
+
+	// Encoding applied to generated strings by GenerateEncoded, e.g. EncodeLatin1. It should return an error
+	// for runes it can't represent. Default is UTF-8.
+	// There's no way to only generate representable runes, so to avoid errors, limit the expression to them,
+	// e.g. use `[\x20-\xff]` instead of `.` for Latin-1.
+	OutputEncoder func([]rune) ([]byte, error)
+
+	// Scheme whose check digits are computed and substituted into generated strings, e.g. Luhn for credit card
+	// numbers. See ChecksumScheme for the strings each scheme requires the expression to generate; NewGenerator
+	// returns an error if it can generate others. Default is NoChecksum.
+	ChecksumScheme ChecksumScheme
+
+	// Called with each rune of every generated string, in order, e.g. to check that no forbidden rune is ever
+	// generated. It sees the final output, after WhitespacePolicy is applied. It is called from the goroutine
+	// calling Generate, so it must be safe for concurrent use if the generator is.
+	OnRune func(r rune)
+
+	// Inserted between the strings generated for each part of an expression that is a sequence, e.g. "\n" to put
+	// each part of `(\w+),(\d+)` on its own line. Only the top level of the expression is split, and a run of
+	// literal characters is a single part. Generated strings containing separators do not match the expression.
+	SegmentSeparator string
+
+	// Script to generate digits from character classes in (e.g. `\d` or `[0-9]`). Literal digits are not affected.
+	// Default is ASCIIDigits. Digits in other scripts are matched by Unicode-aware classes like `\p{Nd}`, but not
+	// by `\d`.
+	DigitScript DigitScript
+
+	// Maximum number of runes generated from each character class and ".". When set, each class picks that many
+	// of its runes at random when the generator is created, and only generates those: strings still match the
+	// expression, but generators with different seeds get a different "flavor" of output, e.g. one generator for
+	// `[a-z]+` may only generate "a", "k", "q" and "x". The subsets are drawn from RngSource, so they are
+	// reproducible. Default is 0, which generates every rune of the class.
+	ClassAlphabetSize int
+
+	// Relative weights of runes encoded in 1, 2, 3 and 4 bytes of UTF-8, when generating from character classes
+	// and "." (e.g. {1, 1, 1, 1} generates each width equally often from `[^a]`, although most of its runes are
+	// 3 or 4 bytes long). Widths the class has no runes of are skipped. Default is all zero, which picks every
+	// rune of the class with equal probability. Widths are those before conversion to DigitScript.
+	UTF8ByteLengthMix [4]float64
+
+	// Transformation applied to whitespace in the generated string, e.g. to collapse the runs of spaces produced
+	// by ` +`. Default is AsIs. Other policies may make generated strings no longer match the expression.
+	WhitespacePolicy WhitespacePolicy
+
+	// Called with the runes generated for each literal and character class, e.g. to instrument generation or to
+	// apply a leetspeak-style transformation. The runes it returns are written in their place. Transformed strings
+	// may no longer match the expression. Default is nil, which writes the runes as generated.
+	OnEmit EmitFunc
+
+	// Set this to accept PCRE conditional groups, e.g. `(?(1)yes|no)` or `(?(<name>)yes)`, which Go's parser
+	// doesn't support. They are rewritten to alternations of their branches, e.g. `(?:yes|no)`, so either branch
+	// is generated whether or not the group the condition refers to was: generated strings may not match the
+	// original expression in PCRE. Conditions other than a group number or name (e.g. lookarounds or recursion)
+	// are rejected. CompiledRegexp returns the rewritten expression.
+	PCRECompat bool
+
+	// Set this to replace placeholder tokens in the expression's literal text when generating:
+	// `{uuid}` is replaced by a random version 4 UUID, drawn from the generator's source.
+	// Generated strings containing replaced placeholders do not match the expression.
+	Placeholders bool
+
+	// Set this to avoid emitting the same string twice in a row from GenerateN and streaming methods such as
+	// Channel.
+	// This is best-effort: a repeated string is regenerated up to 10 times, after which it is emitted anyway
+	// (e.g. for expressions that only match one string).
+	NoAdjacentDuplicates bool
+
+	// Maximum number of strings GenerateUnique generates while looking for distinct ones.
+	// Default is 0, which means 100 times the number of strings asked for.
+	MaxUniqueAttempts int
+
+	// Maximum depth capture groups may be nested to, e.g. 3 for `(a(b(c)))`. Expressions nesting deeper are
+	// rejected by NewGenerator with ErrCaptureNestingTooDeep. This guards CaptureGroupHandlers that recurse into
+	// nested groups against untrusted expressions. Default is 0, which means unlimited.
+	MaxCaptureNesting int
+
+	// Maximum depth of the expression's syntax tree, which generation recurses through, e.g. 3 for `(a+)`: the
+	// group, the repetition and the literal. Deeper expressions are rejected by NewGenerator with
+	// ErrExpressionTooDeep, before generating could exhaust the stack. Default is DefaultMaxDepth.
+	MaxDepth int
+
+	// Maximum number of branches an alternation may have, e.g. 3 for `foo|bar|baz`. Expressions with wider
+	// alternations are rejected by NewGenerator with ErrTooManyAlternationBranches. Note that the parser merges
+	// branches with common prefixes, and single characters into character classes, so `ab|ac|d|e` has 2 branches.
+	// Default is 0, which means unlimited.
+	MaxAlternationBranches int
+
+	// Set this to generate short filler for a ".*" or ".+" the expression begins or ends with, e.g. for
+	// `.*ERROR.*`, which only says the string contains "ERROR". At most SurroundingDotStarMax characters are
+	// generated for each, instead of up to MaxUnboundedRepeatCount.
+	TrimSurroundingDotStar bool
+	// Default is DefaultSurroundingDotStarMax.
+	SurroundingDotStarMax uint
+
+	// Maximum number of lines of printable ASCII to generate before an expression beginning with a `^` that matches
+	// at the start of any line, and after one ending with such a `$`, so that e.g. `(?m)^foo$` generates "foo" on
+	// a line of its own among others, like "a b\nfoo\n~x". Anchors match at line boundaries under syntax.Multiline,
+	// i.e. without syntax.OneLine, which includes the default flags. Such strings contain a match rather than
+	// matching as a whole, so check them with an unanchored multiline expression. Default is 0, which generates no
+	// surrounding lines.
+	SurroundingLines uint
+
+	// Minimum length of generated strings, in runes. Strings are regenerated until they are long enough, and the
+	// number of instances generated for unbounded repeat expressions is raised if that helps reaching it.
+	// NewGenerator returns ErrUnsatisfiable if the expression can't generate strings that long.
+	// Default is 0, which means no minimum.
+	MinTotalLength int
+
+	// Maximum length of generated strings, in runes. The number of instances generated for unbounded repeat
+	// expressions is lowered to keep strings under it, and strings still too long are regenerated; if none fits,
+	// GenerateE returns an error. NewGenerator returns ErrUnsatisfiable if even the shortest strings the expression
+	// generates are longer, e.g. because of the literal text in it.
+	// Default is 0, which means no maximum.
+	MaxTotalLength int
+
+	// Maximum number of bytes a single string may take while it is generated, as a safety valve for untrusted
+	// expressions like `(a*)*` with a large MaxUnboundedRepeatCount. Generating is aborted as soon as it would take
+	// more, and GenerateE returns ErrOutputTooLarge with the size reached, while Generate returns what was generated
+	// so far. Strings generated into buffers, e.g. for capture groups, count while they are held there too.
+	// Unlike MaxTotalLength, it doesn't make strings shorter, it only stops them. Default is 0, which means unlimited.
+	MaxOutputSize int
+
+	// Set this to check every string generated matches the expression as a whole, e.g. to catch a
+	// CaptureGroupHandler or OnEmit generating values that break it. GenerateE returns ErrInvalidOutput for strings
+	// that don't, while Generate returns them anyway. Strings other settings make not match, e.g. WhitespacePolicy
+	// or SurroundingLines, fail too, except that strings of expressions padded to satisfy a word boundary at their
+	// edge, e.g. `\Bfoo`, only need to contain a match. Matching every string is expensive, so it is off by default.
+	// NewGenerator returns an error for expressions Go regexps can't match, e.g. with backreferences.
+	ValidateOutput bool
+	// Number of times to regenerate a string ValidateOutput finds doesn't match, e.g. because a CaptureGroupHandler
+	// only sometimes breaks it, before failing. Requires ValidateOutput. Default is 0, which doesn't regenerate.
+	RetryUntilValid int
+
+	// Set this to make the generator safe to use from several goroutines at once, when RngSource is set.
+	// Generators without an RngSource always are. The source is locked for every random number drawn, so
+	// concurrent calls are only reproducible with a seed if their order is.
+	Concurrent bool
+
+	// Set this to count the alternation branches and repeat counts generated, as returned by Generator.Stats.
+	// Counting takes a lock for every choice made, so it slows generating down.
+	CollectStats bool
+
+	rng *rand.Rand
+	// The source of rng, unless it is defaultRng.
+	source savableSource
+	// Whether the expression has backreferences.
+	backreferences bool
+	// The runes ExcludeRunes leaves, if it is set.
+	allowed *tCharClass
+	// Minimum number of instances to generate for every unbounded repeat, to meet MinTotalLength.
+	unboundedRepeatFloor int
+	// Maximum number of instances to generate for every unbounded repeat, to meet MaxTotalLength.
+	unboundedRepeatCeiling int
+	// Where choices are counted, if CollectStats is set.
+	stats *statsCollector
+	// Names of the expression's capture groups, by number, if CaptureGroupContextHandler is set.
+	capNames []string
 }
 
 func (a *GeneratorArgs) initialize() error {
-	// unicode groups only allowed with Perl
-	if (a.Flags&syntax.UnicodeGroups) == syntax.UnicodeGroups && (a.Flags&syntax.Perl) != syntax.Perl {
-		return generatorError(nil, "UnicodeGroups not supported")
+	if err := a.Profile.apply(a); err != nil {
+		return err
 	}
 
 	if a.MaxUnboundedRepeatCount < 1 {
 		a.MaxUnboundedRepeatCount = DefaultMaxUnboundedRepeatCount
 	}
 
+	if a.SurroundingDotStarMax < 1 {
+		a.SurroundingDotStarMax = DefaultSurroundingDotStarMax
+	}
+
 	if a.MinUnboundedRepeatCount > a.MaxUnboundedRepeatCount {
-		panic(fmt.Sprintf("MinUnboundedRepeatCount(%d) > MaxUnboundedRepeatCount(%d)",
-			a.MinUnboundedRepeatCount, a.MaxUnboundedRepeatCount))
+		return generatorError(nil, "MinUnboundedRepeatCount(%d) > MaxUnboundedRepeatCount(%d)",
+			a.MinUnboundedRepeatCount, a.MaxUnboundedRepeatCount)
+	}
+
+	for _, max := range []struct {
+		name  string
+		value uint
+	}{{"StarMax", a.StarMax}, {"PlusMax", a.PlusMax}, {"OpenRangeMax", a.OpenRangeMax}} {
+		if max.value > 0 && a.MinUnboundedRepeatCount > max.value {
+			return generatorError(nil, "MinUnboundedRepeatCount(%d) > %s(%d)", a.MinUnboundedRepeatCount, max.name,
+				max.value)
+		}
+	}
+
+	for index, probability := range a.OptionalProbabilities {
+		if probability < 0 || probability > 1 {
+			return generatorError(nil, "OptionalProbabilities[%d] must be between 0 and 1, was %v", index, probability)
+		}
 	}
 
+	if a.RetryUntilValid < 0 {
+		return generatorError(nil, "RetryUntilValid must not be negative, was %d", a.RetryUntilValid)
+	}
+	if a.RetryUntilValid > 0 && !a.ValidateOutput {
+		return generatorError(nil, "RetryUntilValid requires ValidateOutput")
+	}
+
+	if a.ClassAlphabetSize < 0 {
+		return generatorError(nil, "ClassAlphabetSize must not be negative, was %d", a.ClassAlphabetSize)
+	}
+
+	for i, weight := range a.UTF8ByteLengthMix {
+		if weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			return generatorError(nil, "UTF8ByteLengthMix[%d] must be a non-negative number, was %v", i, weight)
+		}
+	}
+
+	if _, ok := digitScriptZeros[a.DigitScript]; !ok {
+		return generatorError(nil, "invalid DigitScript: %d", a.DigitScript)
+	}
+
+	if a.WhitespacePolicy < AsIs || a.WhitespacePolicy > NoLeadingTrailing {
+		return generatorError(nil, "invalid WhitespacePolicy: %d", a.WhitespacePolicy)
+	}
+
+	if a.MaxCaptureNesting < 0 {
+		return generatorError(nil, "MaxCaptureNesting must not be negative, was %d", a.MaxCaptureNesting)
+	}
+
+	if a.MaxDepth < 0 {
+		return generatorError(nil, "MaxDepth must not be negative, was %d", a.MaxDepth)
+	}
+	if a.MaxDepth == 0 {
+		a.MaxDepth = DefaultMaxDepth
+	}
+
+	if a.MinTotalLength < 0 {
+		return generatorError(nil, "MinTotalLength must not be negative, was %d", a.MinTotalLength)
+	}
+
+	if a.MaxTotalLength < 0 {
+		return generatorError(nil, "MaxTotalLength must not be negative, was %d", a.MaxTotalLength)
+	}
+	if a.MaxTotalLength > 0 && a.MinTotalLength > a.MaxTotalLength {
+		return generatorError(nil, "MinTotalLength(%d) > MaxTotalLength(%d)", a.MinTotalLength, a.MaxTotalLength)
+	}
+
+	if a.MaxOutputSize < 0 {
+		return generatorError(nil, "MaxOutputSize must not be negative, was %d", a.MaxOutputSize)
+	}
+
+	if a.MaxUniqueAttempts < 0 {
+		return generatorError(nil, "MaxUniqueAttempts must not be negative, was %d", a.MaxUniqueAttempts)
+	}
+
+	for name, pool := range a.GroupValuePools {
+		if len(pool) == 0 {
+			return generatorError(nil, "GroupValuePools[%q] must not be empty", name)
+		}
+	}
+
+	for i, r := range a.AnyCharRunes {
+		if r < 1 || !utf8.ValidRune(r) {
+			return generatorError(nil, "AnyCharRunes[%d] must be a valid rune other than NUL, was %U", i, r)
+		}
+	}
+
+	for i, r := range a.ExcludeRunes {
+		if r < 1 || !utf8.ValidRune(r) {
+			return generatorError(nil, "ExcludeRunes[%d] must be a valid rune other than NUL, was %U", i, r)
+		}
+	}
+	if len(a.ExcludeRunes) > 0 {
+		a.allowed = allowedClass(a.ExcludeRunes)
+	}
+
+	for alternation, weights := range a.AlternationWeights {
+		total := 0.0
+		for i, weight := range weights {
+			if weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+				return generatorError(nil, "AlternationWeights[%q][%d] must be a non-negative number, was %v",
+					alternation, i, weight)
+			}
+			total += weight
+		}
+		if total == 0 {
+			return generatorError(nil, "AlternationWeights[%q] must have a positive weight", alternation)
+		}
+	}
+
+	if a.MaxAlternationBranches < 0 {
+		return generatorError(nil, "MaxAlternationBranches must not be negative, was %d", a.MaxAlternationBranches)
+	}
+
+	handlers := 0
+	for _, set := range []bool{a.CaptureGroupHandler != nil, a.CaptureGroupContextHandler != nil,
+		a.CaptureGroupHandlerE != nil} {
+		if set {
+			handlers++
+		}
+	}
+	if handlers > 1 {
+		return generatorError(nil,
+			"only one of CaptureGroupHandler, CaptureGroupContextHandler and CaptureGroupHandlerE may be set")
+	}
 	if a.CaptureGroupHandler == nil {
 		a.CaptureGroupHandler = defaultCaptureGroupHandler
 	}
 
+	if a.Rand != nil && (a.RngSource != nil || a.Seed != nil) {
+		return generatorError(nil, "Rand must not be set together with RngSource or Seed")
+	}
+
+	if a.Seed != nil {
+		if a.RngSource != nil {
+			return generatorError(nil, "Seed and RngSource must not both be set")
+		}
+		a.RngSource = rand.NewSource(*a.Seed)
+	}
+
+	if a.Rand != nil {
+		a.rng = a.Rand
+	} else if a.RngSource == nil {
+		a.rng = defaultRng
+	} else if _, ok := a.RngSource.(cryptoSource); ok {
+		// Used directly, since strings generated from a source seeded from it would be predictable from each other.
+		a.rng = rand.New(cryptoSource{})
+	} else {
+		a.seed(saltSeed(a.RngSource.Int63(), a.Salt))
+	}
+
 	return nil
 }
 
+// seed gives the args a new source of their own, seeded with seed.
+func (a *GeneratorArgs) seed(seed int64) {
+	a.source = newXorShift64Source(seed)
+	if a.Concurrent {
+		a.source = &lockedSource{src: a.source}
+	}
+	a.rng = rand.New(a.source)
+}
+
+// Rng returns the random number generator used by generators created with these args.
+// Panics if the args have not been initialized by creating a generator with them.
+func (a *GeneratorArgs) Rng() *rand.Rand {
+	if a.rng == nil {
+		panic("GeneratorArgs has not been initialized")
+	}
+	return a.rng
+}
+
 // Generator generates random strings.
 type Generator interface {
 	Generate() string
 	String() string
+
+	// GenerateN generates n strings. It returns an empty slice if n is 0.
+	GenerateN(n int) []string
+
+	// Clone returns a generator for the same expression, with its own source seeded from this generator's.
+	Clone() Generator
+
+	// GenerateContext is like Generate, but returns early with ctx.Err() if ctx is cancelled while generating.
+	GenerateContext(ctx context.Context) (string, error)
+
+	// GenerateE is like Generate, but returns an error instead of a string that doesn't meet the constraints set
+	// in GeneratorArgs, e.g. MaxTotalLength, or whose CaptureGroupHandlerE failed.
+	GenerateE() (string, error)
+
+	// GenerateRand is like Generate, but draws random numbers from rng instead of the generator's source.
+	GenerateRand(rng *rand.Rand) string
+
+	// GenerateBytes is like Generate, but returns a byte slice owned by the caller.
+	GenerateBytes() []byte
+
+	// WriteTo generates a string and writes it to w as it is generated, returning the number of bytes written
+	// and the first error returned by w.
+	WriteTo(w io.Writer) (int64, error)
+
+	// CompiledRegexp returns the generator's expression compiled for matching, e.g. to check generated strings
+	// with the same semantics the generator used.
+	CompiledRegexp() (*regexp.Regexp, error)
+
+	// Channel returns a channel of generated strings with the given buffer size. The channel is closed, and the
+	// goroutine filling it exits, when ctx is cancelled.
+	Channel(ctx context.Context, buffer int) <-chan string
+
+	// Stream returns an unbuffered channel of generated strings, closed when ctx is cancelled.
+	Stream(ctx context.Context) <-chan string
+
+	// Reader returns a reader of an endless stream of generated strings, with sep between them.
+	Reader(sep string) io.Reader
+
+	// GenerateUntil generates up to max strings, passing each to stop, until stop returns true.
+	// It returns the strings generated, and whether stop returned true.
+	GenerateUntil(stop func(s string) bool, max int) ([]string, bool)
+
+	// GenerateWithFailingEdit generates a string, and a copy with a single-character edit at byte offset pos
+	// that makes it no longer match. If no such edit is found, broken is empty and pos is -1.
+	GenerateWithFailingEdit() (valid string, broken string, pos int)
+
+	// GenerateNonMatching returns a string a single edit away from a generated one that doesn't match the
+	// generator's expression, or an error if none is found.
+	GenerateNonMatching() (string, error)
+
+	// GenerateSatisfying generates strings until one satisfies predicate, retrying according to policy.
+	GenerateSatisfying(predicate func(string) bool, policy RetryPolicy) (string, error)
+
+	// GenerateSatisfyingWithToken is like GenerateSatisfying, but also returns a token GenerateFromToken
+	// reproduces the result from.
+	GenerateSatisfyingWithToken(predicate func(string) bool, policy RetryPolicy) (value, token string, err error)
+
+	// GenerateExcluding generates strings until one is not in excluded, retrying according to policy.
+	GenerateExcluding(excluded []string, policy RetryPolicy) (string, error)
+
+	// GenerateUnique generates n distinct strings, giving up after GeneratorArgs.MaxUniqueAttempts attempts.
+	GenerateUnique(n int) ([]string, error)
+
+	// Alphabet returns every rune the generator could emit, sorted.
+	Alphabet() []rune
+
+	// GenerateParsableAs generates strings until one is a valid instance of kind, e.g. an Int32, making at most
+	// maxAttempts attempts.
+	GenerateParsableAs(kind DataKind, maxAttempts int) (string, error)
+
+	// GenerateWithHash generates a string, and returns it with its hash computed by h.
+	GenerateWithHash(h hash.Hash) (string, []byte)
+
+	// GenerateEncoded generates a string, and returns it encoded with GeneratorArgs.OutputEncoder.
+	GenerateEncoded() ([]byte, error)
+
+	// DiverseSamples returns n generated strings picked to differ from each other as much as possible.
+	DiverseSamples(n int) []string
+
+	// CaptureGroups returns the capture groups of the generator's expression, in order of their index.
+	CaptureGroups() []CaptureGroup
+
+	// GenerateWithRepeatedGroups generates a string, and returns it with the values generated for each capture
+	// group, keyed by 0-based index.
+	GenerateWithRepeatedGroups() (string, map[int][]string)
+
+	// GenerateWithCaptures generates a string, and returns it with the last value generated for each capture
+	// group, by name for named groups and by 0-based index for all of them.
+	GenerateWithCaptures() (s string, named map[string]string, indexed []string)
+
+	// GenerateWithSpans generates a string, and returns it with the byte offsets of the values of its capture
+	// groups in it.
+	GenerateWithSpans() (string, []CaptureSpan)
+
+	// GenerateShortest returns the shortest string the generator generates, chosen deterministically.
+	GenerateShortest() string
+
+	// GenerateLongest returns the longest string the generator generates, chosen deterministically.
+	GenerateLongest() string
+
+	// Enumerate returns every string of at most maxLen runes the generator's expression matches, sorted.
+	Enumerate(maxLen int) []string
+
+	// Cardinality returns the number of distinct strings of at most maxLen runes the generator's expression matches.
+	Cardinality(maxLen int) *big.Int
+
+	// NumMatches returns the number of different ways the generator can generate a string, or nil if there are too
+	// many to count, e.g. for `(.*)*`.
+	NumMatches() *big.Int
+
+	// EstimateCollisions returns the expected number of duplicates generated while generating n distinct strings,
+	// and whether the generator can generate n distinct strings at all.
+	EstimateCollisions(n int) (expectedRetries float64, feasible bool)
+
+	// GenerateWithToken generates a string, and returns it with a token GenerateFromToken reproduces it from, e.g.
+	// for a bug report.
+	//
+	// Each call that returns a token draws a seed from the generator's source, and generates from a source seeded
+	// with it instead. The token is that seed as 16 hexadecimal digits, a dot, and the number of strings generated
+	// from it (more than one if some were rejected by a filter, at most MaxTokenAttempts), e.g.
+	// "9b2f41c07d55e0a3.4". The last of those strings is the one the token identifies.
+	//
+	// A token only reproduces its string with a generator for the same expression and GeneratorArgs (other than
+	// RngSource and Salt), created by the same version of this package. Values of GroupValuePools are the
+	// exception: the generator cycles through them call after call, so the string reproduced has whichever values
+	// come next. Set RandomGroupValuePools for values tokens reproduce too.
+	GenerateWithToken() (value, token string)
+
+	// GenerateFromToken reproduces the string a token was returned with. It returns an error for generators
+	// without an expression, e.g. from NewScheduledGenerator.
+	GenerateFromToken(token string) (string, error)
+
+	// SnapshotState returns the state of the generator's source, or nil if it has no RngSource.
+	SnapshotState() []byte
+
+	// RestoreState restores the state of the generator's source from a snapshot returned by SnapshotState.
+	RestoreState(snapshot []byte) error
+
+	// Reset reseeds the generator's source, so that it generates the same strings as a generator created with
+	// Seed set to seed.
+	Reset(seed int64)
+
+	// Stats returns the alternation branches and repeat counts generated so far, if GeneratorArgs.CollectStats
+	// is set.
+	Stats() Stats
+
+	// ResetStats clears the stats returned by Stats.
+	ResetStats()
 }
 
 /*
@@ -170,30 +800,245 @@ func Generate(pattern string) (string, error) {
 	return generator.Generate(), nil
 }
 
+// GenerateN generates n random strings that match the regular expression pattern.
+func GenerateN(pattern string, n int) ([]string, error) {
+	generator, err := NewGenerator(pattern, nil)
+	if err != nil {
+		return nil, err
+	}
+	return generator.GenerateN(n), nil
+}
+
+// Must is like NewGenerator with default args, but panics if pattern can't be parsed. It is meant for tests and
+// initializing package variables, where a bad pattern is a programming error.
+func Must(pattern string) Generator {
+	generator, err := NewGenerator(pattern, nil)
+	if err != nil {
+		panic(`regen: Must(` + strconv.Quote(pattern) + `): ` + err.Error())
+	}
+	return generator
+}
+
+// MustGenerate is like Generate, but panics if pattern can't be parsed. Like Must, it is meant for tests and
+// initialization.
+func MustGenerate(pattern string) string {
+	return Must(pattern).Generate()
+}
+
 // NewGenerator creates a generator that returns random strings that match the regular expression in pattern.
 // If args is nil, default values are used.
 func NewGenerator(pattern string, inputArgs *GeneratorArgs) (generator Generator, err error) {
-	args := GeneratorArgs{}
+	args, matchesExpression, err := copyArgs(inputArgs)
+	if err != nil {
+		return nil, err
+	}
 
-	// Copy inputArgs so the caller can't change them.
-	if inputArgs != nil {
-		args = *inputArgs
+	if args.PCRECompat {
+		if pattern, err = rewriteConditionals(pattern); err != nil {
+			return nil, err
+		}
 	}
-	if err = args.initialize(); err != nil {
+
+	var references int
+	var names []string
+	if pattern, references, names, err = rewriteBackreferences(pattern, args.Flags); err != nil {
 		return nil, err
 	}
+	args.backreferences = references > 0 || len(names) > 0
+	matchesExpression = matchesExpression && !args.backreferences
 
 	var regexp *syntax.Regexp
 	regexp, err = syntax.Parse(pattern, args.Flags)
 	if err != nil {
 		return
 	}
+	if references > regexp.MaxCap() {
+		return nil, generatorError(nil, "backreference \\%d refers to a group that doesn't exist", references)
+	}
+	if err = resolveNamedBackreferences(regexp, names); err != nil {
+		return nil, err
+	}
+	return newGeneratorFromSyntax(regexp, &args, matchesExpression)
+}
+
+/*
+NewGeneratorFromSyntax creates a generator for an expression already parsed, e.g. to simplify it first or to parse
+it with flags of its own. args.Flags is still used for what the parsed expression doesn't record, like matching
+generated strings in CompiledRegexp. The generator keeps re, which must not be changed afterwards.
+*/
+func NewGeneratorFromSyntax(re *syntax.Regexp, args *GeneratorArgs) (Generator, error) {
+	if re == nil {
+		return nil, generatorError(nil, "regexp must not be nil")
+	}
+	copied, matchesExpression, err := copyArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return newGeneratorFromSyntax(re, &copied, matchesExpression)
+}
+
+// copyArgs returns a copy of inputArgs, so the caller can't change them, with defaults filled in, and whether the
+// strings generated with them are meant to match the expression, so that assertions can be checked against it.
+func copyArgs(inputArgs *GeneratorArgs) (args GeneratorArgs, matchesExpression bool, err error) {
+	if inputArgs != nil {
+		args = *inputArgs
+	}
+	matchesExpression = args.CaptureGroupHandler == nil && args.CaptureGroupContextHandler == nil &&
+		args.CaptureGroupHandlerE == nil && len(args.GroupValuePools) == 0 && len(args.FixedGroups) == 0 &&
+		!args.Placeholders && args.SegmentSeparator == "" && args.DigitScript == ASCIIDigits && args.OnEmit == nil
+	err = args.initialize()
+	return args, matchesExpression, err
+}
+
+// newGeneratorFromSyntax creates a generator for a parsed expression, with args copied and initialized.
+func newGeneratorFromSyntax(regexp *syntax.Regexp, args *GeneratorArgs, matchesExpression bool) (Generator, error) {
+	var err error
+	if args.MaxCaptureNesting > 0 {
+		if nesting := captureNesting(regexp); nesting > args.MaxCaptureNesting {
+			return nil, generatorError(ErrCaptureNestingTooDeep, "capture groups nested %d deep, MaxCaptureNesting is %d",
+				nesting, args.MaxCaptureNesting)
+		}
+	}
+	if depth := expressionDepth(regexp); depth > args.MaxDepth {
+		return nil, generatorError(ErrExpressionTooDeep, "expression reaches depth %d, MaxDepth is %d",
+			depth, args.MaxDepth)
+	}
+
+	if sub := unmatchable(regexp); sub != nil {
+		return nil, generatorError(ErrUnsatisfiable, "/%s/ can never match: /%s/ matches nothing", regexp, sub)
+	}
+
+	if args.MaxTotalLength > 0 {
+		if err = args.lowerUnboundedRepeatCeiling(regexp); err != nil {
+			return nil, err
+		}
+	}
+	if err = args.checkAlternationWeights(regexp); err != nil {
+		return nil, err
+	}
+
+	if args.MinTotalLength > 0 {
+		if err = args.raiseUnboundedRepeatFloor(regexp); err != nil {
+			return nil, err
+		}
+	}
+
+	if !satisfiable(regexp) {
+		return nil, generatorError(ErrUnsatisfiable, "/%s/ can never match", regexp)
+	}
+
+	source := regexp
+	if args.TrimSurroundingDotStar {
+		regexp = trimSurroundingDotStar(regexp, int(args.SurroundingDotStarMax))
+	}
+	if args.allowed != nil {
+		emptied := make(map[*syntax.Regexp]*syntax.Regexp)
+		regexp = excludeRunes(regexp, args.allowed, emptied)
+		if sub := unmatchable(regexp); sub != nil {
+			if original, ok := emptied[sub]; ok {
+				sub = original
+			}
+			return nil, generatorError(nil, "/%s/ has no runes left to generate once ExcludeRunes are removed", sub)
+		}
+	}
+
+	if args.CollectStats {
+		args.stats = newStatsCollector()
+	}
+	if args.CaptureGroupContextHandler != nil {
+		args.capNames = regexp.CapNames()
+	}
 
 	var gen *internalGenerator
-	gen, err = newGenerator(regexp, &args)
+	gen, err = newAssertedGenerator(regexp, args, matchesExpression)
+	padded := errors.Is(err, ErrUnsatisfiable)
+	if padded {
+		gen, err = newPaddedGenerator(regexp, args, matchesExpression, err)
+	}
 	if err != nil {
-		return
+		return nil, err
+	}
+	// Trimmed filler and excluded runes still match the original expression, so report that one from
+	// CompiledRegexp.
+	gen.regexp = source
+	if args.SurroundingLines > 0 {
+		gen = newSurroundingLinesGenerator(gen, regexp, int(args.SurroundingLines))
+	}
+
+	gen = newRootGenerator(gen, args)
+	if args.MinTotalLength > 0 || args.MaxTotalLength > 0 {
+		max := args.MaxTotalLength
+		if max == 0 {
+			max = math.MaxInt32
+		}
+		if gen, err = newLengthBoundedGenerator(gen, args.MinTotalLength, max); err != nil {
+			return nil, err
+		}
+	}
+	if args.ValidateOutput {
+		if gen, err = newValidatingGenerator(gen, args.RetryUntilValid, padded); err != nil {
+			return nil, err
+		}
+	}
+	if args.MaxOutputSize > 0 {
+		// Outermost, so that the strings regenerated for other limits share one budget.
+		gen = newSizeLimitedGenerator(gen, args.MaxOutputSize)
+	}
+	return gen, nil
+}
+
+/*
+NewGeneratorFromRegexp creates a generator for the expression re was compiled from, so that what is matched and
+what is generated can't get out of sync. Flags set in the expression, e.g. `(?i)`, are kept with it. If args.Flags
+is 0, the expression is parsed with syntax.Perl, like regexp.Compile does, or with syntax.POSIX if Perl syntax
+rejects it. An expression compiled with regexp.CompilePOSIX can't be told apart from one compiled with
+regexp.Compile, so it is parsed with syntax.Perl too if Perl syntax accepts it.
+*/
+func NewGeneratorFromRegexp(re *regexp.Regexp, args *GeneratorArgs) (Generator, error) {
+	if re == nil {
+		return nil, generatorError(nil, "regexp must not be nil")
+	}
+
+	var copied GeneratorArgs
+	if args != nil {
+		copied = *args
+	}
+	pattern := re.String()
+	if copied.Flags == 0 {
+		copied.Flags = syntax.Perl
+		if _, err := syntax.Parse(pattern, syntax.Perl); err != nil {
+			copied.Flags = syntax.POSIX
+		}
+	}
+	return NewGenerator(pattern, &copied)
+}
+
+// newAssertedGenerator creates the generator for a whole expression, making sure the assertions in it hold if
+// matchesExpression.
+func newAssertedGenerator(regexp *syntax.Regexp, args *GeneratorArgs, matchesExpression bool) (*internalGenerator, error) {
+	narrowed, ok := narrowAssertions(regexp)
+	if !ok {
+		return nil, generatorError(ErrUnsatisfiable, "/%s/ can never match: its assertions conflict", regexp)
+	}
+
+	if err := args.ChecksumScheme.check(narrowed, args); err != nil {
+		return nil, err
 	}
 
+	var gen *internalGenerator
+	var err error
+	if args.SegmentSeparator != "" && narrowed.Op == syntax.OpConcat {
+		gen, err = newSegmentedGenerator(narrowed, args)
+	} else {
+		gen, err = newGenerator(narrowed, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+	gen.regexp = regexp
+
+	if matchesExpression && hasInnerAssertions(regexp) {
+		return newAssertingGenerator(gen)
+	}
 	return gen, nil
 }
@@ -18,6 +18,8 @@ limitations under the License.
 package regen
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -74,3 +76,33 @@ func BenchmarkLargeRepeatGenerateSerial(b *testing.B) {
 		generator.Generate()
 	}
 }
+
+// An alternation of 500 branches without common prefixes, so the parser doesn't factor it into narrower ones.
+func wideAlternation() string {
+	branches := make([]string, 500)
+	for i := range branches {
+		branches[i] = fmt.Sprintf("%c%03d", 'A'+i%26, i)
+	}
+	return strings.Join(branches, "|")
+}
+
+func BenchmarkWideAlternationCreation(b *testing.B) {
+	pattern := wideAlternation()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		NewGenerator(pattern, &GeneratorArgs{})
+	}
+}
+
+func BenchmarkWideAlternationGeneration(b *testing.B) {
+	generator, err := NewGenerator(wideAlternation(), &GeneratorArgs{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		generator.Generate()
+	}
+}
@@ -18,12 +18,20 @@ limitations under the License.
 package regen
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"os"
 	"regexp"
 	"regexp/syntax"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -115,7 +123,7 @@ func TestGeneratorArgs(t *testing.T) {
 			}
 		})
 
-		t.Run("Unicode groups not supported", func(t *testing.T) {
+		t.Run("Allows Unicode groups without Perl", func(t *testing.T) {
 			t.Parallel()
 
 			args := &GeneratorArgs{
@@ -123,12 +131,12 @@ func TestGeneratorArgs(t *testing.T) {
 			}
 
 			err := args.initialize()
-			if err.Error() != "UnicodeGroups not supported" {
-				t.Fatal("should be UnicodeGroups not supported")
+			if err != nil {
+				t.Fatalf("err should be nil")
 			}
 		})
 
-		t.Run("Panics if repeat bounds are invalid", func(t *testing.T) {
+		t.Run("Fails if repeat bounds are invalid", func(t *testing.T) {
 			t.Parallel()
 
 			args := &GeneratorArgs{
@@ -136,13 +144,13 @@ func TestGeneratorArgs(t *testing.T) {
 				MaxUnboundedRepeatCount: 1,
 			}
 
-			defer func() {
-				if r := recover(); r == nil {
-					t.Fatal("The code did not panic")
-				}
-			}()
-
-			args.initialize()
+			err := args.initialize()
+			if err == nil || err.Error() != "MinUnboundedRepeatCount(2) > MaxUnboundedRepeatCount(1)" {
+				t.Fatal("should be MinUnboundedRepeatCount(2) > MaxUnboundedRepeatCount(1):", err)
+			}
+			if _, err = NewGenerator("a*", args); err == nil {
+				t.Fatal("err should not be nil")
+			}
 		})
 
 		t.Run("Allows equal repeat bounds", func(t *testing.T) {
@@ -163,6 +171,18 @@ func TestGeneratorArgs(t *testing.T) {
 	t.Run("Rng", func(t *testing.T) {
 		t.Parallel()
 
+		t.Run("Panics when called before initialization", func(t *testing.T) {
+			t.Parallel()
+
+			args := GeneratorArgs{}
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatal("The code did not panic")
+				}
+			}()
+			args.Rng()
+		})
+
 		t.Run("Non-nil after initialization", func(t *testing.T) {
 			t.Parallel()
 
@@ -171,6 +191,46 @@ func TestGeneratorArgs(t *testing.T) {
 			if err != nil {
 				t.Fatalf("err should be nil")
 			}
+			if args.Rng() == nil {
+				t.Fatalf("rng should not be nil")
+			}
+		})
+
+		t.Run("Salt changes the generated strings", func(t *testing.T) {
+			t.Parallel()
+
+			generate := func(salt string) (result string) {
+				gen, _ := NewGenerator("[a-z]{8}", &GeneratorArgs{
+					RngSource: rand.NewSource(42),
+					Salt:      []byte(salt),
+				})
+				for i := 0; i < 10; i++ {
+					result += gen.Generate()
+				}
+				return
+			}
+
+			if generate("tenant-a") != generate("tenant-a") {
+				t.Fatal("same salt should generate the same strings")
+			}
+			if generate("tenant-a") == generate("tenant-b") {
+				t.Fatal("different salts should generate different strings")
+			}
+			if generate("tenant-a") == generate("") {
+				t.Fatal("salted and unsalted should generate different strings")
+			}
+		})
+
+		t.Run("Same seed generates the same strings", func(t *testing.T) {
+			t.Parallel()
+
+			gen1, _ := NewGenerator("[a-z]{10}", &GeneratorArgs{RngSource: rand.NewSource(42)})
+			gen2, _ := NewGenerator("[a-z]{10}", &GeneratorArgs{RngSource: rand.NewSource(42)})
+			for i := 0; i < 10; i++ {
+				if gen1.Generate() != gen2.Generate() {
+					t.Fatal("should be equal")
+				}
+			}
 		})
 	})
 }
@@ -206,7 +266,8 @@ func TestNewGenerator(t *testing.T) {
 		t.Parallel()
 
 		args := &GeneratorArgs{
-			Flags: syntax.UnicodeGroups,
+			MinUnboundedRepeatCount: 2,
+			MaxUnboundedRepeatCount: 1,
 		}
 
 		_, err := NewGenerator("", args)
@@ -216,6 +277,90 @@ func TestNewGenerator(t *testing.T) {
 	})
 }
 
+func TestNewGeneratorFromRegexp(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Parses with Perl flags", func(t *testing.T) {
+		t.Parallel()
+
+		re := regexp.MustCompile(`(?i)\d{3}-(?:ab|cd)`)
+		generator, err := NewGeneratorFromRegexp(re, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			if s := generator.Generate(); !re.MatchString(s) {
+				t.Fatalf("%q should match /%s/", s, re)
+			}
+		}
+	})
+
+	t.Run("Keeps args", func(t *testing.T) {
+		t.Parallel()
+
+		re := regexp.MustCompile(`a*`)
+		generator, err := NewGeneratorFromRegexp(re, &GeneratorArgs{MinUnboundedRepeatCount: 5, MaxUnboundedRepeatCount: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := generator.Generate(); s != "aaaaa" {
+			t.Fatal("should be equal:", s, "aaaaa")
+		}
+	})
+
+	t.Run("Fails on nil", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewGeneratorFromRegexp(nil, nil); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+func TestNewGeneratorFromSyntax(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Generates from parsed expression", func(t *testing.T) {
+		t.Parallel()
+
+		parsed, err := syntax.Parse(`[a-c]{2,3}(?:x|y)`, syntax.Perl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = parsed.Simplify()
+		generator, err := NewGeneratorFromSyntax(parsed, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := regexp.MustCompile(`^[a-c]{2,3}[xy]$`)
+		for i := 0; i < SampleSize; i++ {
+			if s := generator.Generate(); !expected.MatchString(s) {
+				t.Fatalf("%q should match /%s/", s, expected)
+			}
+		}
+	})
+
+	t.Run("Checks parsed expression", func(t *testing.T) {
+		t.Parallel()
+
+		parsed, err := syntax.Parse(`a[^\x00-\x{10FFFF}]`, syntax.Perl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = NewGeneratorFromSyntax(parsed, nil); !errors.Is(err, ErrUnsatisfiable) {
+			t.Fatal("err should be ErrUnsatisfiable:", err)
+		}
+	})
+
+	t.Run("Fails on nil", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewGeneratorFromSyntax(nil, nil); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
 func TestGenEmpty(t *testing.T) {
 	t.Parallel()
 
@@ -233,6 +378,63 @@ func TestGenLiterals(t *testing.T) {
 	)
 }
 
+func TestGenEscapes(t *testing.T) {
+	t.Parallel()
+
+	for pattern, expected := range map[string]string{
+		`\x41\xFF\x{263A}`:    "A\u00ff\u263a",
+		`\x{1F600}\x{10FFFD}`: "\U0001F600\U0010FFFD",
+		`\101\0\01\177`:       "A\x00\x01\x7f",
+		`\cK\ca\cZ`:           "\v\x01\x1a",
+		`(a)\1\101`:           "aaA",
+		`(?i)\x{10400}`:       "",
+	} {
+		generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatalf("/%s/: %v", pattern, err)
+		}
+		for i := 0; i < 10; i++ {
+			s := generator.Generate()
+			if expected == "" {
+				// 𐐀 and 𐐨 are case variants in the Deseret script.
+				if s != "\U00010400" && s != "\U00010428" {
+					t.Fatalf("/%s/ generated %q", pattern, s)
+				}
+			} else if s != expected {
+				t.Fatalf("/%s/ should generate %q, generated %q", pattern, expected, s)
+			}
+		}
+	}
+
+	if _, err := NewGenerator(`\c1`, &GeneratorArgs{Flags: syntax.Perl}); err == nil {
+		t.Fatal("err should not be nil")
+	}
+}
+
+func TestGenQuotedLiterals(t *testing.T) {
+	t.Parallel()
+
+	for pattern, expected := range map[string]string{
+		`\Qa.b*\E`:          "a.b*",
+		`x\Q(a)\1[\E]`:      "x(a)\\1[]",
+		`\Q\cK\Z\101\E`:     `\cK\Z\101`,
+		`(a)\Q\1\E\1`:       `a\1a`,
+		`\Q(?(1)a|b)`:       "(?(1)a|b)",
+		`\Q{uuid}\E{2}`:     "{uuid}}",
+		`(?i:\Qa.\E){0}\Q*`: "*",
+	} {
+		generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, PCRECompat: true})
+		if err != nil {
+			t.Fatalf("/%s/: %v", pattern, err)
+		}
+		for i := 0; i < 10; i++ {
+			if s := generator.Generate(); s != expected {
+				t.Fatalf("/%s/ should generate %q, generated %q", pattern, expected, s)
+			}
+		}
+	}
+}
+
 func TestGenDotNotNl(t *testing.T) {
 	t.Parallel()
 
@@ -250,6 +452,40 @@ func TestGenDotNotNl(t *testing.T) {
 	}
 }
 
+func TestGenDotNL(t *testing.T) {
+	t.Parallel()
+
+	// Newlines are one rune in a million otherwise.
+	anyChar := []rune("ab\n")
+
+	for _, tc := range []struct {
+		pattern string
+		flags   syntax.Flags
+		newline bool
+	}{
+		{`.{100}`, 0, false},
+		{`.{100}`, syntax.DotNL, true},
+		{`.{100}`, syntax.MatchNL, true},
+		{`(?s).{100}`, syntax.PerlX, true},
+	} {
+		generator, err := NewGenerator(tc.pattern, &GeneratorArgs{Flags: tc.flags, AnyCharRunes: anyChar})
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for i := 0; i < SampleSize && !found; i++ {
+			found = strings.Contains(generator.Generate(), "\n")
+		}
+		if found != tc.newline {
+			t.Fatalf("/%s/ with flags %v should generate a newline: %v", tc.pattern, tc.flags, tc.newline)
+		}
+	}
+
+	// Classes still don't match newlines without ClassNL.
+	GeneratesStringMatching(t, &GeneratorArgs{Flags: syntax.DotNL, AnyCharRunes: []rune("\n")}, `.[^a]{100}`,
+		"^\n[^\n]{100}$")
+}
+
 func TestGenStringStartEnd(t *testing.T) {
 	t.Parallel()
 
@@ -258,8 +494,38 @@ func TestGenStringStartEnd(t *testing.T) {
 	}
 
 	GeneratesStringMatching(t, args, `^abc$`, `^abc$`)
-	GeneratesStringMatching(t, args, `$abc^`, `^abc$`)
-	GeneratesStringMatching(t, args, `a^b$c`, `^abc$`)
+
+	t.Run("Anchors that may be satisfied", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`^a$\n^b$`, `a\n?^b`, `a$\n?`, `(a|\n)^b`, `a(^b|c)`} {
+			if _, err := NewGenerator(pattern, args); err != nil {
+				t.Fatalf("/%s/ should be satisfiable, was %v", pattern, err)
+			}
+		}
+		for _, pattern := range []string{`^a?\Ab$`, `a\z|b`, `(?m)a$\nb`} {
+			if _, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl}); err != nil {
+				t.Fatalf("/%s/ should be satisfiable, was %v", pattern, err)
+			}
+		}
+	})
+
+	t.Run("Misplaced anchors are unsatisfiable", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`a$b`, `a^b`, `^a$b$`, `$abc^`, `a^b$c`, `(a)(^b)`, `(a$b)+`, `a$b|c^d`} {
+			_, err := NewGenerator(pattern, args)
+			if !errors.Is(err, ErrUnsatisfiable) {
+				t.Fatalf("/%s/ should be ErrUnsatisfiable, was %v", pattern, err)
+			}
+		}
+		for _, pattern := range []string{`a\zb`, `a\Ab`, `a$b`} {
+			_, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl})
+			if !errors.Is(err, ErrUnsatisfiable) {
+				t.Fatalf("/%s/ should be ErrUnsatisfiable, was %v", pattern, err)
+			}
+		}
+	})
 }
 
 func TestGenQuestionMark(t *testing.T) {
@@ -347,6 +613,68 @@ func TestGenStar(t *testing.T) {
 	})
 }
 
+func TestPerOperatorRepeatMax(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Bounds each operator", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Flags: syntax.Perl, StarMax: 2, PlusMax: 3, OpenRangeMax: 50}
+		GeneratesStringMatching(t, args, `a*`, `^a{0,2}$`)
+		GeneratesStringMatching(t, args, `a+`, `^a{1,3}$`)
+		GeneratesStringMatching(t, args, `\d{3,}`, `^\d{3,50}$`)
+
+		generator, _ := NewGenerator(`a*-\d{3,}`, args)
+		if s := generator.GenerateLongest(); len(s) != 2+1+50 {
+			t.Fatal("should be equal:", len(s), 2+1+50)
+		}
+		longest := 0
+		for i := 0; i < SampleSize; i++ {
+			if s := generator.Generate(); len(s) > longest {
+				longest = len(s)
+			}
+		}
+		if longest <= 2+1+3+3 {
+			t.Fatal("open ranges should be bounded by OpenRangeMax, not PlusMax:", longest)
+		}
+	})
+
+	t.Run("Falls back to MaxUnboundedRepeatCount", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{MaxUnboundedRepeatCount: 5, PlusMax: 2}
+		generator, _ := NewGenerator(`a*-b+-c{1,}`, args)
+		if s := generator.GenerateLongest(); s != "aaaaa-bb-ccccc" {
+			t.Fatal("should be equal:", s, "aaaaa-bb-ccccc")
+		}
+	})
+
+	t.Run("Min must not exceed the maximums", func(t *testing.T) {
+		t.Parallel()
+
+		for _, args := range []*GeneratorArgs{
+			{MinUnboundedRepeatCount: 3, StarMax: 2},
+			{MinUnboundedRepeatCount: 3, PlusMax: 2},
+			{MinUnboundedRepeatCount: 3, OpenRangeMax: 2},
+		} {
+			if _, err := NewGenerator(`a*`, args); err == nil {
+				t.Fatalf("%+v: err should not be nil", args)
+			}
+		}
+	})
+
+	t.Run("Open ranges above OpenRangeMax generate their minimum", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`a{5,}`, &GeneratorArgs{OpenRangeMax: 3})
+		for i := 0; i < 10; i++ {
+			if s := generator.Generate(); s != "aaaaa" {
+				t.Fatal("should be aaaaa:", s)
+			}
+		}
+	})
+}
+
 func TestGenCharClassNotNl(t *testing.T) {
 	t.Parallel()
 	GeneratesStringMatchingItself(t, nil,
@@ -493,6 +821,50 @@ func TestGenRepeat(t *testing.T) {
 	})
 }
 
+func TestGenUnicodeClasses(t *testing.T) {
+	t.Parallel()
+
+	for _, flags := range []syntax.Flags{syntax.Perl, syntax.UnicodeGroups} {
+		args := &GeneratorArgs{Flags: flags}
+		GeneratesStringMatchingItself(t, args, `^\p{Lu}{5}$`, `^\p{Greek}+$`, `^\p{Nd}\PL\P{Greek}$`,
+			`^[\p{Han}\p{Cyrillic}]{3}$`)
+	}
+
+	t.Run("Uppercase letters", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`\p{Lu}{5}`, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			s := generator.Generate()
+			if utf8.RuneCountInString(s) != 5 {
+				t.Fatalf("should be 5 runes: %q", s)
+			}
+			for _, r := range s {
+				if !unicode.IsUpper(r) {
+					t.Fatalf("%q should be uppercase in %q", r, s)
+				}
+			}
+		}
+	})
+
+	t.Run("Complement", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`\PL`, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			if r := []rune(generator.Generate())[0]; unicode.IsLetter(r) {
+				t.Fatalf("%q should not be a letter", r)
+			}
+		}
+	})
+}
+
 func TestGenCharClasses(t *testing.T) {
 	t.Parallel()
 
@@ -602,6 +974,856 @@ func TestCaptureGroupHandler(t *testing.T) {
 	}
 }
 
+func TestCaptureGroupContextHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Sees earlier groups", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(?P<first>[a-z]{3}) (\d) (?:(x)|y)(z)`, &GeneratorArgs{
+			Flags: syntax.Perl,
+			CaptureGroupContextHandler: func(group *CaptureGroupContext) string {
+				switch group.Index {
+				case 1:
+					return group.Values[0] + group.NamedValues["first"]
+				case 3:
+					if x, ok := group.Values[2]; ok {
+						return x + "!"
+					}
+					return "?"
+				}
+				return group.Generator.Generate()
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < SampleSize; i++ {
+			s := generator.Generate()
+			parts := strings.Split(s, " ")
+			if len(parts) != 3 || parts[1] != parts[0]+parts[0] {
+				t.Fatalf("second group should repeat the first twice: %q", s)
+			}
+			if parts[2] != "xx!" && parts[2] != "y?" {
+				t.Fatalf("last group should see the optional group: %q", s)
+			}
+		}
+	})
+
+	t.Run("Values are per string", func(t *testing.T) {
+		t.Parallel()
+
+		var seen []int
+		generator, err := NewGenerator(`(a)(b)`, &GeneratorArgs{
+			CaptureGroupContextHandler: func(group *CaptureGroupContext) string {
+				seen = append(seen, len(group.Values))
+				return group.Generator.Generate()
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		generator.Generate()
+		generator.Generate()
+		if fmt.Sprint(seen) != "[0 1 0 1]" {
+			t.Fatal("should be equal:", seen, "[0 1 0 1]")
+		}
+	})
+
+	t.Run("Can't be set with CaptureGroupHandler", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewGenerator(`(a)`, &GeneratorArgs{
+			CaptureGroupHandler:        defaultCaptureGroupHandler,
+			CaptureGroupContextHandler: func(group *CaptureGroupContext) string { return "" },
+		})
+		if err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+func TestCaptureGroupHandlerE(t *testing.T) {
+	t.Parallel()
+
+	errEmpty := errors.New("no more values")
+	newGenerator := func(t *testing.T, values []string) Generator {
+		generator, err := NewGenerator(`id=(\d+), name=([a-z]+)`, &GeneratorArgs{
+			Flags: syntax.Perl,
+			CaptureGroupHandlerE: func(index int, name string, group *syntax.Regexp, generator Generator,
+				args *GeneratorArgs) (string, error) {
+				if index == 0 {
+					return generator.Generate(), nil
+				}
+				if len(values) == 0 {
+					return "", errEmpty
+				}
+				value := values[0]
+				values = values[1:]
+				if value == "" {
+					return "", errEmpty
+				}
+				return value, nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return generator
+	}
+
+	t.Run("Returns values", func(t *testing.T) {
+		t.Parallel()
+
+		generator := newGenerator(t, []string{"ann"})
+		s, err := generator.GenerateE()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(s, "id=") || !strings.HasSuffix(s, ", name=ann") {
+			t.Fatalf("wrong output: %q", s)
+		}
+	})
+
+	t.Run("Aborts on error", func(t *testing.T) {
+		t.Parallel()
+
+		generator := newGenerator(t, nil)
+		s, err := generator.GenerateE()
+		if !errors.Is(err, errEmpty) {
+			t.Fatal("err should be errEmpty:", err)
+		}
+		if s != "" {
+			t.Fatal("should be empty:", s)
+		}
+		if s = generator.Generate(); !strings.HasPrefix(s, "id=") || !strings.HasSuffix(s, ", name=") {
+			t.Fatalf("should stop at the failing group: %q", s)
+		}
+	})
+
+	t.Run("Aborts only the failing string", func(t *testing.T) {
+		t.Parallel()
+
+		generator := newGenerator(t, []string{"", "bob"})
+		results := generator.GenerateN(2)
+		if !strings.HasSuffix(results[0], "name=") || !strings.HasSuffix(results[1], "name=bob") {
+			t.Fatal("wrong output:", results)
+		}
+	})
+}
+
+func TestMaxCaptureNesting(t *testing.T) {
+	t.Parallel()
+
+	pattern := strings.Repeat("(", 20) + "a" + strings.Repeat(")", 20)
+
+	t.Run("Rejects deeper nesting", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewGenerator(pattern, &GeneratorArgs{MaxCaptureNesting: 19})
+		if !errors.Is(err, ErrCaptureNestingTooDeep) {
+			t.Fatalf("should be ErrCaptureNestingTooDeep, was %v", err)
+		}
+	})
+
+	t.Run("Allows nesting up to the limit", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{MaxCaptureNesting: 20}, pattern, "^a$")
+		GeneratesStringMatching(t, &GeneratorArgs{MaxCaptureNesting: 1}, "(a)(b)", "^ab$")
+	})
+
+	t.Run("Unlimited by default", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, nil, pattern, "^a$")
+	})
+}
+
+func TestMaxDepth(t *testing.T) {
+	t.Parallel()
+
+	// 6 groups, each repeated, around a literal: 13 deep.
+	pattern := strings.Repeat("(", 6) + "a" + strings.Repeat(")+", 6)
+
+	t.Run("Rejects deeper expressions", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewGenerator(pattern, &GeneratorArgs{MaxDepth: 12})
+		if !errors.Is(err, ErrExpressionTooDeep) {
+			t.Fatalf("should be ErrExpressionTooDeep, was %v", err)
+		}
+		if !strings.Contains(err.Error(), "depth 13") {
+			t.Fatalf("%q should contain the depth reached", err)
+		}
+	})
+
+	t.Run("Allows depth up to the limit", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{MaxDepth: 13, MaxUnboundedRepeatCount: 2}
+		GeneratesStringMatching(t, args, pattern, "^a+$")
+		GeneratesStringMatching(t, &GeneratorArgs{MaxDepth: 1}, "a", "^a$")
+	})
+
+	t.Run("Defaults to DefaultMaxDepth", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator("a", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if depth := generator.(*internalGenerator).args.MaxDepth; depth != DefaultMaxDepth {
+			t.Fatal("should be equal:", depth, DefaultMaxDepth)
+		}
+		if _, err := NewGenerator("a", &GeneratorArgs{MaxDepth: -1}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+func TestGenerateN(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Generates n strings", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`[a-f]{4}`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results := generator.GenerateN(SampleSize)
+		if len(results) != SampleSize {
+			t.Fatalf("should generate %d strings, generated %d", SampleSize, len(results))
+		}
+		for _, s := range results {
+			if matched, _ := regexp.MatchString(`^[a-f]{4}$`, s); !matched {
+				t.Fatalf("%q should match", s)
+			}
+		}
+
+		if empty := generator.GenerateN(0); empty == nil || len(empty) != 0 {
+			t.Fatal("should be empty and not nil")
+		}
+	})
+
+	t.Run("Same sequence as Generate", func(t *testing.T) {
+		t.Parallel()
+
+		args := func() *GeneratorArgs {
+			return &GeneratorArgs{RngSource: rand.NewSource(7)}
+		}
+		batch, _ := NewGenerator(`[a-z]{8}`, args())
+		single, _ := NewGenerator(`[a-z]{8}`, args())
+		for i, s := range batch.GenerateN(10) {
+			if expected := single.Generate(); s != expected {
+				t.Fatalf("string %d should be %q, was %q", i, expected, s)
+			}
+		}
+	})
+
+	t.Run("Package level", func(t *testing.T) {
+		t.Parallel()
+
+		results, err := GenerateN(`\d`, 3)
+		if err == nil || results != nil {
+			t.Fatal("POSIX syntax should reject \\d")
+		}
+		if results, err = GenerateN(`[0-9]`, 3); err != nil || len(results) != 3 {
+			t.Fatalf("should generate 3 strings, was %q, %v", results, err)
+		}
+	})
+}
+
+func TestMust(t *testing.T) {
+	t.Parallel()
+
+	if s := Must(`[0-9]{3}`).Generate(); len(s) != 3 {
+		t.Fatalf("should generate 3 digits, was %q", s)
+	}
+	if s := MustGenerate(`abc`); s != "abc" {
+		t.Fatalf("should be abc, was %q", s)
+	}
+
+	for _, must := range []func(){
+		func() { Must(`\d`) },
+		func() { MustGenerate(`a(`) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("should panic")
+				}
+			}()
+			must()
+		}()
+	}
+}
+
+func TestSeed(t *testing.T) {
+	t.Parallel()
+
+	seed := int64(12)
+	seeded, err := NewGenerator(`[a-z]{10}`, &GeneratorArgs{Seed: &seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withSource, _ := NewGenerator(`[a-z]{10}`, &GeneratorArgs{RngSource: rand.NewSource(seed)})
+	for i := 0; i < 10; i++ {
+		if s, expected := seeded.Generate(), withSource.Generate(); s != expected {
+			t.Fatalf("should be equal: %q, %q", s, expected)
+		}
+	}
+
+	_, err = NewGenerator(`a`, &GeneratorArgs{Seed: &seed, RngSource: rand.NewSource(seed)})
+	if err == nil {
+		t.Fatal("err should not be nil")
+	}
+}
+
+func TestRand(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewSource(3))
+	gen, err := NewGenerator(`[a-z]{10}`, &GeneratorArgs{Rand: rng})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := rand.New(rand.NewSource(3))
+	for i := 0; i < 10; i++ {
+		s := gen.Generate()
+		for _, r := range s {
+			if e := rune('a' + expected.Intn(26)); r != e {
+				t.Fatalf("should draw from Rand: generated %q, expected %q at %q", s, e, r)
+			}
+		}
+	}
+
+	seed := int64(5)
+	gen.Reset(seed)
+	seeded, _ := NewGenerator(`[a-z]{10}`, &GeneratorArgs{Seed: &seed})
+	if s, other := gen.Generate(), seeded.Generate(); s != other {
+		t.Fatalf("Reset should seed a source of its own: %q, %q", s, other)
+	}
+
+	seed = 3
+	for name, args := range map[string]*GeneratorArgs{
+		"RngSource": {Rand: rng, RngSource: rand.NewSource(seed)},
+		"Seed":      {Rand: rng, Seed: &seed},
+	} {
+		if _, err := NewGenerator(`a`, args); err == nil {
+			t.Fatalf("Rand with %s: err should not be nil", name)
+		}
+	}
+}
+
+func TestCryptoRngSource(t *testing.T) {
+	t.Parallel()
+
+	first, _ := NewGenerator(`[a-z]{32}`, &GeneratorArgs{RngSource: CryptoRngSource()})
+	second, _ := NewGenerator(`[a-z]{32}`, &GeneratorArgs{RngSource: CryptoRngSource()})
+	if s, other := first.Generate(), second.Generate(); s == other {
+		t.Fatalf("should generate different strings, both generated %q", s)
+	}
+	if first.SnapshotState() != nil {
+		t.Fatal("should have no state to snapshot")
+	}
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	args := func() *GeneratorArgs {
+		return &GeneratorArgs{RngSource: rand.NewSource(9)}
+	}
+
+	t.Run("Clones diverge", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`[a-z]{10}`, args())
+		first, second := generator.Clone(), generator.Clone()
+		same := true
+		for i := 0; i < 10 && same; i++ {
+			s := first.Generate()
+			if matched, _ := regexp.MatchString(`^[a-z]{10}$`, s); !matched {
+				t.Fatalf("%q does not match", s)
+			}
+			same = s == second.Generate()
+		}
+		if same {
+			t.Fatal("clones should generate different strings")
+		}
+	})
+
+	t.Run("Clones don't share the source", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`[a-z]{10}`, args())
+		reference, _ := NewGenerator(`[a-z]{10}`, args())
+		clone := generator.Clone()
+		reference.Clone()
+
+		for i := 0; i < 10; i++ {
+			clone.Generate()
+			if s, expected := generator.Generate(), reference.Generate(); s != expected {
+				t.Fatalf("should be equal: %q, %q", s, expected)
+			}
+		}
+	})
+
+	t.Run("Clones are reproducible", func(t *testing.T) {
+		t.Parallel()
+
+		first, _ := NewGenerator(`[a-z]{10}`, args())
+		second, _ := NewGenerator(`[a-z]{10}`, args())
+		if s, expected := first.Clone().Generate(), second.Clone().Generate(); s != expected {
+			t.Fatalf("should be equal: %q, %q", s, expected)
+		}
+	})
+}
+
+func TestConcurrent(t *testing.T) {
+	t.Parallel()
+
+	// Run with -race to check.
+	generator, err := NewGenerator(`[a-z]+(-[0-9]{1,3})*`, &GeneratorArgs{
+		RngSource:               rand.NewSource(1),
+		Concurrent:              true,
+		MaxUnboundedRepeatCount: 8,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pattern := regexp.MustCompile(`^[a-z]+(-[0-9]{1,3})*$`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < SampleSize; j++ {
+				if s := generator.Generate(); !pattern.MatchString(s) {
+					t.Errorf("%q does not match", s)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestUnmatchable(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Rejected with the subexpression", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`[^\x00-\x{10FFFF}]`, `a[^\x00-\x{10FFFF}]b`, `([^\x00-\x{10FFFF}])+`,
+			`(?:[^\x00-\x{10FFFF}]){2,3}`} {
+			_, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl})
+			if !errors.Is(err, ErrUnsatisfiable) {
+				t.Fatalf("/%s/ should be ErrUnsatisfiable, was %v", pattern, err)
+			}
+			if !strings.Contains(err.Error(), `/[^\x00-\x{10FFFF}]/ matches nothing`) {
+				t.Fatalf("error for /%s/ should name the empty class: %v", pattern, err)
+			}
+		}
+
+		_, err := NewGenerator(`([^\x00-\x{10FFFF}])|([^\x00-\x{10FFFF}])`, &GeneratorArgs{Flags: syntax.Perl})
+		if !errors.Is(err, ErrUnsatisfiable) {
+			t.Fatal("should be ErrUnsatisfiable, was", err)
+		}
+	})
+
+	t.Run("Skipped when optional", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Flags: syntax.Perl}
+		GeneratesStringMatching(t, args, `a[^\x00-\x{10FFFF}]*b`, `^ab$`)
+		GeneratesStringMatching(t, args, `a([^\x00-\x{10FFFF}])?b`, `^ab$`)
+		GeneratesStringMatching(t, args, `a(?:[^\x00-\x{10FFFF}]){0,3}b`, `^ab$`)
+		GeneratesStringMatching(t, args, `(x)|([^\x00-\x{10FFFF}])`, `^x$`)
+		GeneratesStringMatching(t, &GeneratorArgs{Flags: syntax.Perl, OptionalProbabilities: map[int]float64{0: 1}},
+			`a([^\x00-\x{10FFFF}])?b`, `^ab$`)
+	})
+}
+
+func TestGenerateContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Same as Generate when not cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		args := func() *GeneratorArgs {
+			return &GeneratorArgs{RngSource: rand.NewSource(3)}
+		}
+		withContext, _ := NewGenerator(`[a-z]+(-[0-9]{2})?`, args())
+		without, _ := NewGenerator(`[a-z]+(-[0-9]{2})?`, args())
+		for i := 0; i < SampleSize; i++ {
+			s, err := withContext.GenerateContext(context.Background())
+			if err != nil {
+				t.Fatal("err should be nil:", err)
+			}
+			if expected := without.Generate(); s != expected {
+				t.Fatalf("should be equal: %q, %q", s, expected)
+			}
+		}
+	})
+
+	t.Run("Returns the error of a cancelled context", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`abc`, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if s, err := generator.GenerateContext(ctx); err != context.Canceled || s != "" {
+			t.Fatalf("should return context.Canceled, returned %q, %v", s, err)
+		}
+	})
+
+	t.Run("Stops inside a huge repetition", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`x.*`, &GeneratorArgs{
+			MinUnboundedRepeatCount: 1 << 30,
+			MaxUnboundedRepeatCount: 1 << 30,
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		if _, err := generator.GenerateContext(ctx); err != context.DeadlineExceeded {
+			t.Fatal("should be equal:", err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Fatal("should stop promptly, took", elapsed)
+		}
+	})
+}
+
+func TestGenerateBytes(t *testing.T) {
+	t.Parallel()
+
+	pattern := `(?i:[a-z]+)\d{2,}[^\n]?|\s*x`
+	args := func() *GeneratorArgs {
+		return &GeneratorArgs{Flags: syntax.Perl, RngSource: rand.NewSource(11)}
+	}
+	bytesGenerator, _ := NewGenerator(pattern, args())
+	stringGenerator, _ := NewGenerator(pattern, args())
+	for i := 0; i < SampleSize; i++ {
+		b, s := bytesGenerator.GenerateBytes(), stringGenerator.Generate()
+		if string(b) != s {
+			t.Fatalf("should be equal: %q, %q", b, s)
+		}
+	}
+}
+
+func TestClassAlphabetSize(t *testing.T) {
+	t.Parallel()
+
+	alphabet := func(t *testing.T, seed int64, pattern string) map[rune]bool {
+		generator, err := NewGenerator(pattern, &GeneratorArgs{
+			Flags:             syntax.Perl,
+			RngSource:         rand.NewSource(seed),
+			ClassAlphabetSize: 5,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		runes := map[rune]bool{}
+		for i := 0; i < SampleSize; i++ {
+			for _, r := range generator.Generate() {
+				runes[r] = true
+			}
+		}
+		return runes
+	}
+
+	t.Run("Seeds pick different subsets", func(t *testing.T) {
+		t.Parallel()
+
+		first, second := alphabet(t, 1, `[a-z]`), alphabet(t, 2, `[a-z]`)
+		if len(first) != 5 || len(second) != 5 {
+			t.Fatalf("should generate 5 runes, generated %d and %d", len(first), len(second))
+		}
+		same := true
+		for r := range first {
+			if r < 'a' || r > 'z' {
+				t.Fatalf("%q should match [a-z]", r)
+			}
+			same = same && second[r]
+		}
+		if same {
+			t.Fatal("different seeds should pick different subsets")
+		}
+	})
+
+	t.Run("Subsets are reproducible", func(t *testing.T) {
+		t.Parallel()
+
+		first, second := alphabet(t, 3, `.+`), alphabet(t, 3, `.+`)
+		if len(first) != 5 || len(first) != len(second) {
+			t.Fatalf("should generate the same 5 runes, generated %d and %d", len(first), len(second))
+		}
+		for r := range first {
+			if !second[r] {
+				t.Fatal("the same seed should pick the same subset")
+			}
+		}
+	})
+
+	t.Run("Small classes are unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		if len(alphabet(t, 4, `[abc]`)) != 3 {
+			t.Fatal("should generate the whole class")
+		}
+	})
+}
+
+func TestAnyCharRunes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Restricts dot", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Flags: syntax.Perl, AnyCharRunes: []rune("abcdef0123456789")}
+		GeneratesStringMatching(t, args, `.{8}`, `^[a-f0-9]{8}$`)
+		GeneratesStringMatching(t, args, `(?s:.{8})`, `^[a-f0-9]{8}$`)
+	})
+
+	t.Run("Leaves classes unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{AnyCharRunes: []rune("ab")}, `[x-z].`, `^[x-z][ab]$`)
+	})
+
+	t.Run("Newline only if dot matches it", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Flags: syntax.Perl, AnyCharRunes: []rune("a\n")}
+		GeneratesStringMatching(t, args, `.{8}`, `^a{8}$`)
+
+		generator, err := NewGenerator(`(?s:.{100})`, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := generator.Generate(); !strings.Contains(s, "\n") {
+			t.Fatalf("%q should contain a newline", s)
+		}
+
+		if _, err := NewGenerator(`.`, &GeneratorArgs{AnyCharRunes: []rune("\n")}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+
+	t.Run("Invalid runes", func(t *testing.T) {
+		t.Parallel()
+
+		for _, runes := range [][]rune{{0}, {'a', 0xD800}, {-1}} {
+			if _, err := NewGenerator(`.`, &GeneratorArgs{AnyCharRunes: runes}); err == nil {
+				t.Fatalf("%q: err should not be nil", runes)
+			}
+		}
+	})
+}
+
+func TestASCIIOnly(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Restricts dot and classes", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Flags: syntax.Perl, ASCIIOnly: true}
+		for _, pattern := range []string{`[^a]{10}`, `.{10}`, `\W{10}`, `\S{10}`, `\D{10}`} {
+			generator, err := NewGenerator(pattern, args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < SampleSize; i++ {
+				s := generator.Generate()
+				for _, r := range s {
+					if r > unicode.MaxASCII {
+						t.Fatalf("/%s/ generated %q, which isn't ASCII", pattern, s)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Literals are unaffected", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{ASCIIOnly: true}, `é[aé]`, `^éa$`)
+
+		if _, err := NewGenerator(`[é-ê]`, &GeneratorArgs{ASCIIOnly: true}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+func TestPrintableOnly(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Restricts dot and classes", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Flags: syntax.Perl, PrintableOnly: true}
+		for _, pattern := range []string{`.{20}`, `(?s:.{20})`, `[^a]{20}`, `[\x00-\x{10FFFF}]{20}`} {
+			generator, err := NewGenerator(pattern, args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < SampleSize; i++ {
+				s := generator.Generate()
+				for _, r := range s {
+					if !unicode.IsPrint(r) {
+						t.Fatalf("/%s/ generated %q, which has unprintable %U", pattern, s, r)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Combines with ASCIIOnly", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{PrintableOnly: true, ASCIIOnly: true}, `.{20}`, `^[ -~]{20}$`)
+	})
+
+	t.Run("Unprintable classes", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`[[:cntrl:]]`, `a[\x{7f}-\x{9f}]`} {
+			if _, err := NewGenerator(pattern, &GeneratorArgs{PrintableOnly: true}); err == nil {
+				t.Fatalf("/%s/: err should not be nil", pattern)
+			}
+		}
+	})
+}
+
+func TestOnRune(t *testing.T) {
+	t.Parallel()
+
+	var runes []rune
+	generator, err := NewGenerator(`[a-z]{10}`, &GeneratorArgs{
+		OnRune: func(r rune) {
+			runes = append(runes, r)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < SampleSize; i++ {
+		runes = runes[:0]
+		s := generator.Generate()
+		if len(runes) != 10 || string(runes) != s {
+			t.Fatalf("should be called with each rune of %q, was %q", s, string(runes))
+		}
+	}
+}
+
+func TestMaxAlternationBranches(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Rejects wider alternations", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewGenerator(`x(ab|cd|ef)`, &GeneratorArgs{MaxAlternationBranches: 2})
+		if !errors.Is(err, ErrTooManyAlternationBranches) {
+			t.Fatalf("should be ErrTooManyAlternationBranches, was %v", err)
+		}
+		if _, err := NewGenerator(`a`, &GeneratorArgs{MaxAlternationBranches: -1}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+
+	t.Run("Allows alternations up to the limit", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{MaxAlternationBranches: 3}, `x(ab|cd|ef)`, `^x(ab|cd|ef)$`)
+		GeneratesStringMatching(t, &GeneratorArgs{MaxAlternationBranches: 1}, `ab|ac`, `^a[bc]$`)
+		GeneratesStringMatching(t, nil, wideAlternation(), `^[A-Z]\d{3}$`)
+	})
+}
+
+func TestOptionalProbabilities(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Presence matches configured probability", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(a)?(b)?(c)?`, &GeneratorArgs{
+			OptionalProbabilities: map[int]float64{0: 0.9, 1: 0.2},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		const iterations = 10000
+		counts := map[rune]int{}
+		for i := 0; i < iterations; i++ {
+			for _, r := range generator.Generate() {
+				counts[r]++
+			}
+		}
+
+		for r, expected := range map[rune]float64{'a': 0.9, 'b': 0.2, 'c': 0.5} {
+			actual := float64(counts[r]) / iterations
+			if math.Abs(actual-expected) > 0.03 {
+				t.Fatalf("%c present %v of the time, expected %v", r, actual, expected)
+			}
+		}
+	})
+
+	t.Run("Rejects invalid probabilities", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewGenerator(`(a)?`, &GeneratorArgs{
+			OptionalProbabilities: map[int]float64{0: 1.5},
+		})
+		if err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+func TestCompiledRegexp(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Matches generated strings", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(?i)[a-f]{3}\d+x|y`, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		re, err := generator.CompiledRegexp()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			s := generator.Generate()
+			if !re.MatchString(s) {
+				t.Fatalf("%q does not match /%s/", s, re)
+			}
+		}
+	})
+
+	t.Run("Is cached", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("abc", nil)
+		re1, _ := generator.CompiledRegexp()
+		re2, _ := generator.CompiledRegexp()
+		if re1 != re2 {
+			t.Fatal("should return the same regexp")
+		}
+	})
+}
+
 func GeneratesStringMatchingItself(t *testing.T, args *GeneratorArgs, patterns ...string) {
 	for _, pattern := range patterns {
 		s := ShouldGenerateStringMatching(pattern, pattern, args)
@@ -0,0 +1,57 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"sync"
+)
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Generator{}
+)
+
+// Register creates a generator for pattern and stores it under name, to be retrieved later with Get.
+// It returns an error if the pattern is invalid or name is already registered.
+//
+// Generators are typically registered from init functions or test setup, and retrieved from anywhere afterwards.
+// Register and Get are safe for concurrent use.
+func Register(name, pattern string, args *GeneratorArgs) error {
+	generator, err := NewGenerator(pattern, args)
+	if err != nil {
+		return generatorError(err, "failed to register generator %q", name)
+	}
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if _, ok := registry[name]; ok {
+		return generatorError(nil, "generator %q already registered", name)
+	}
+	registry[name] = generator
+	return nil
+}
+
+// Get returns the generator registered under name by Register.
+func Get(name string) (Generator, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	generator, ok := registry[name]
+	return generator, ok
+}
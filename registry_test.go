@@ -0,0 +1,83 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Get returns registered generators", func(t *testing.T) {
+		t.Parallel()
+
+		if err := Register("registry-test-hex", "[0-9a-f]{8}", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				generator, ok := Get("registry-test-hex")
+				if !ok {
+					t.Error("generator should be registered")
+					return
+				}
+				if matched, _ := regexp.MatchString("^[0-9a-f]{8}$", generator.Generate()); !matched {
+					t.Error("should match")
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("Get reports unknown names", func(t *testing.T) {
+		t.Parallel()
+
+		if _, ok := Get("registry-test-unknown"); ok {
+			t.Fatal("should not be registered")
+		}
+	})
+
+	t.Run("Rejects duplicate names", func(t *testing.T) {
+		t.Parallel()
+
+		if err := Register("registry-test-duplicate", "a", nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := Register("registry-test-duplicate", "b", nil); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+
+	t.Run("Rejects invalid patterns", func(t *testing.T) {
+		t.Parallel()
+
+		if err := Register("registry-test-invalid", "a(", nil); err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if _, ok := Get("registry-test-invalid"); ok {
+			t.Fatal("should not be registered")
+		}
+	})
+}
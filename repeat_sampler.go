@@ -0,0 +1,66 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// RepeatCountSampler picks the number of instances to generate for a repeat expression, between min and max
+// inclusive, e.g. 2 and 8 for `a{2,8}`. Counts outside the range are clamped to it.
+type RepeatCountSampler func(min, max int, rng *rand.Rand) int
+
+// UniformRepeat picks every count between min and max with equal probability, as generators do by default for
+// every repeat expression except bounded ranges like "{2,8}".
+func UniformRepeat(min, max int, rng *rand.Rand) int {
+	return min + rng.Intn(max-min+1)
+}
+
+// GeometricRepeat returns a sampler picking min plus the number of failed trials before the first success, each
+// succeeding with probability p, so that counts near min are most likely. E.g. with p = 0.5 `a*` generates "" half
+// of the time and "a" a quarter. Counts past max are clamped to it. It panics if p isn't in (0, 1].
+func GeometricRepeat(p float64) RepeatCountSampler {
+	if !(p > 0 && p <= 1) {
+		panic(fmt.Sprintf("GeometricRepeat: p must be in (0, 1], was %v", p))
+	}
+	return func(min, max int, rng *rand.Rand) int {
+		if p == 1 {
+			return min
+		}
+		// Inverse transform: the number of failures is floor(ln(U) / ln(1-p)).
+		failures := math.Floor(math.Log(1-rng.Float64()) / math.Log(1-p))
+		if failures >= float64(max-min) {
+			return max
+		}
+		return min + int(failures)
+	}
+}
+
+// sample calls sampler, clamping its count to [min, max].
+func (sampler RepeatCountSampler) sample(min, max int, rng *rand.Rand) int {
+	n := sampler(min, max, rng)
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
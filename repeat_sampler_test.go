@@ -0,0 +1,97 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRepeatCountSampler(t *testing.T) {
+	t.Parallel()
+
+	lengths := func(t *testing.T, pattern string, sampler RepeatCountSampler) map[int]int {
+		generator, err := NewGenerator(pattern, &GeneratorArgs{RepeatCountSampler: sampler, MaxUnboundedRepeatCount: 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := map[int]int{}
+		for i := 0; i < SampleSize; i++ {
+			counts[len(generator.Generate())]++
+		}
+		return counts
+	}
+
+	t.Run("Picks every count", func(t *testing.T) {
+		t.Parallel()
+
+		toMax := func(min, max int, rng *rand.Rand) int { return max }
+		if counts := lengths(t, `a{2,8}`, toMax); counts[8] != SampleSize {
+			t.Fatal("should always generate the maximum:", counts)
+		}
+		if counts := lengths(t, `a*`, toMax); counts[20] != SampleSize {
+			t.Fatal("should always generate the unbounded maximum:", counts)
+		}
+		if counts := lengths(t, `a?`, toMax); counts[1] != SampleSize {
+			t.Fatal("should always generate the optional expression:", counts)
+		}
+	})
+
+	t.Run("Clamps counts", func(t *testing.T) {
+		t.Parallel()
+
+		wild := func(min, max int, rng *rand.Rand) int { return []int{-5, 100}[rng.Intn(2)] }
+		counts := lengths(t, `a{2,8}`, wild)
+		if counts[2]+counts[8] != SampleSize || counts[2] == 0 || counts[8] == 0 {
+			t.Fatal("should clamp to 2 and 8:", counts)
+		}
+	})
+
+	t.Run("UniformRepeat", func(t *testing.T) {
+		t.Parallel()
+
+		counts := lengths(t, `a{2,8}`, UniformRepeat)
+		for n := 2; n <= 8; n++ {
+			if counts[n] == 0 {
+				t.Fatalf("should generate %d runes: %v", n, counts)
+			}
+		}
+	})
+
+	t.Run("GeometricRepeat", func(t *testing.T) {
+		t.Parallel()
+
+		counts := lengths(t, `a{2,8}`, GeometricRepeat(0.5))
+		if counts[2] < SampleSize*4/10 || counts[2] > SampleSize*6/10 {
+			t.Fatal("should generate the minimum about half of the time:", counts)
+		}
+		if counts[2] <= counts[3] || counts[3] <= counts[5] || counts[1] != 0 {
+			t.Fatal("should favor short counts:", counts)
+		}
+		if counts := lengths(t, `a{2,8}`, GeometricRepeat(1)); counts[2] != SampleSize {
+			t.Fatal("should always generate the minimum:", counts)
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("should panic")
+			}
+		}()
+		GeometricRepeat(0)
+	})
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+/*
+NewScheduledGenerator creates a generator that delegates each call to one of generators. schedule is called with
+the index of the call (starting at 0) and returns the index of the generator to use for it, e.g. to change the mix
+of generated values over time.
+
+If schedule returns an index out of range, nothing is generated for the call, and GenerateE returns an error. So
+does a generator failing to generate for the call. GenerateRand draws random numbers from its rng in generators
+created by NewGenerator too, though which one is used still depends on the schedule.
+
+NumMatches and Cardinality add up those of generators, Alphabet and Enumerate combine theirs, and GenerateShortest
+and GenerateLongest pick the shortest or longest of theirs. Other methods that depend on an expression, like
+CompiledRegexp, are not supported by the returned generator, and its strings can't be reproduced with
+GenerateFromToken.
+*/
+func NewScheduledGenerator(generators []Generator, schedule func(callIndex int) int) (Generator, error) {
+	if len(generators) == 0 {
+		return nil, generatorError(nil, "no generators to schedule")
+	}
+	if schedule == nil {
+		return nil, generatorError(nil, "schedule must not be nil")
+	}
+
+	generators = append([]Generator(nil), generators...)
+	names := make([]string, len(generators))
+	for i, generator := range generators {
+		names[i] = generator.String()
+	}
+
+	var calls int64
+	name := fmt.Sprintf("schedule(%s)", strings.Join(names, ", "))
+	return &internalGenerator{Name: name, scheduled: generators, GenerateFunc: func(state *generatorState) {
+		callIndex := int(atomic.AddInt64(&calls, 1) - 1)
+		i := schedule(callIndex)
+		if i < 0 || i >= len(generators) {
+			state.abort(generatorError(nil, "schedule returned index %d for call %d, but there are %d generators",
+				i, callIndex, len(generators)))
+			return
+		}
+		s, err := generateScheduled(generators[i], state)
+		if err != nil {
+			state.fail(err)
+		}
+		state.out.WriteString(s)
+	}}, nil
+}
+
+// generateScheduled generates a string with generator for a scheduled generator generating with state. Generators
+// created by NewGenerator draw random numbers from the source state was given, e.g. by GenerateRand, if it isn't
+// the default one, rather than from their own.
+func generateScheduled(generator Generator, state *generatorState) (string, error) {
+	delegate, ok := generator.(*internalGenerator)
+	if !ok {
+		return generator.GenerateE()
+	}
+	delegateState := delegate.newState()
+	if state.rng != defaultRng {
+		delegateState.rng = state.rng
+	}
+	delegateState.done = state.done
+	s := delegate.generateString(delegateState)
+	return s, delegateState.err
+}
+
+// scheduledAlphabet returns the sorted, non-overlapping ranges of runes any of generators could emit.
+func scheduledAlphabet(generators []Generator) []tCharClassRange {
+	var ranges []tCharClassRange
+	for _, generator := range generators {
+		if delegate, ok := generator.(*internalGenerator); ok {
+			ranges = append(ranges, delegate.alphabetRanges()...)
+			continue
+		}
+		for _, r := range generator.Alphabet() {
+			ranges = append(ranges, tCharClassRange{Start: r, Size: 1})
+		}
+	}
+	return mergeCharClassRanges(ranges)
+}
+
+// scheduledExtreme returns the first shortest (or longest, if longest) of the strings GenerateShortest (or
+// GenerateLongest) returns for generators.
+func scheduledExtreme(generators []Generator, longest bool) string {
+	var best string
+	for i, generator := range generators {
+		s := generator.GenerateShortest()
+		if longest {
+			s = generator.GenerateLongest()
+		}
+		length, bestLength := utf8.RuneCountInString(s), utf8.RuneCountInString(best)
+		if i == 0 || (longest && length > bestLength) || (!longest && length < bestLength) {
+			best = s
+		}
+	}
+	return best
+}
+
+// scheduledEnumeration returns the sorted strings of at most maxLen runes any of generators enumerates.
+func scheduledEnumeration(generators []Generator, maxLen int) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, generator := range generators {
+		for _, s := range generator.Enumerate(maxLen) {
+			if !seen[s] {
+				seen[s] = true
+				result = append(result, s)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestNewScheduledGenerator(t *testing.T) {
+	t.Parallel()
+
+	a, _ := NewGenerator("a", nil)
+	b, _ := NewGenerator("b", nil)
+
+	t.Run("Follows the schedule", func(t *testing.T) {
+		t.Parallel()
+
+		// Two a's, then one b.
+		generator, err := NewScheduledGenerator([]Generator{a, b}, func(callIndex int) int {
+			if callIndex%3 == 2 {
+				return 1
+			}
+			return 0
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var result string
+		for i := 0; i < 9; i++ {
+			result += generator.Generate()
+		}
+		if result != "aabaabaab" {
+			t.Fatalf("wrong output: %q", result)
+		}
+		if generator.String() != "schedule(a, b)" {
+			t.Fatalf("wrong name: %q", generator.String())
+		}
+	})
+
+	t.Run("Fails on out of range indices", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewScheduledGenerator([]Generator{a, b}, func(int) int { return 2 })
+		if s, err := generator.GenerateE(); err == nil || s != "" {
+			t.Fatalf("should fail, was %q, %v", s, err)
+		}
+		if s := generator.Generate(); s != "" {
+			t.Fatalf("should generate nothing, was %q", s)
+		}
+	})
+
+	t.Run("Fails with its generators", func(t *testing.T) {
+		t.Parallel()
+
+		failing, _ := NewScheduledGenerator([]Generator{a}, func(int) int { return 1 })
+		generator, _ := NewScheduledGenerator([]Generator{failing}, func(int) int { return 0 })
+		if _, err := generator.GenerateE(); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+
+	t.Run("Draws from the rng given", func(t *testing.T) {
+		t.Parallel()
+
+		digits, _ := NewGenerator(`[0-9]{10}`, nil)
+		generator, _ := NewScheduledGenerator([]Generator{digits}, func(int) int { return 0 })
+		first := generator.GenerateRand(rand.New(rand.NewSource(1)))
+		if second := generator.GenerateRand(rand.New(rand.NewSource(1))); first != second {
+			t.Fatalf("should be equal: %q, %q", first, second)
+		}
+	})
+
+	t.Run("Combines expression methods", func(t *testing.T) {
+		t.Parallel()
+
+		long, _ := NewGenerator(`b{2,3}`, nil)
+		generator, _ := NewScheduledGenerator([]Generator{a, long}, func(int) int { return 0 })
+		if s := generator.GenerateShortest(); s != "a" {
+			t.Fatalf("wrong shortest: %q", s)
+		}
+		if s := generator.GenerateLongest(); s != "bbb" {
+			t.Fatalf("wrong longest: %q", s)
+		}
+		if s := generator.Enumerate(2); !reflect.DeepEqual(s, []string{"a", "bb"}) {
+			t.Fatalf("wrong enumeration: %q", s)
+		}
+		if s := generator.Alphabet(); string(s) != "ab" {
+			t.Fatalf("wrong alphabet: %q", string(s))
+		}
+	})
+
+	t.Run("Counts", func(t *testing.T) {
+		t.Parallel()
+
+		ab, _ := NewGenerator("[ab]", nil)
+		generator, _ := NewScheduledGenerator([]Generator{a, ab}, func(int) int { return 0 })
+		if n := generator.NumMatches(); n == nil || n.Int64() != 3 {
+			t.Fatalf("NumMatches should be 3, was %v", n)
+		}
+		if n := generator.Cardinality(1); n == nil || n.Int64() != 3 {
+			t.Fatalf("Cardinality should be 3, was %v", n)
+		}
+	})
+
+	t.Run("Rejects invalid arguments", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewScheduledGenerator(nil, func(int) int { return 0 }); err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if _, err := NewScheduledGenerator([]Generator{a}, nil); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
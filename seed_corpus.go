@@ -0,0 +1,56 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+// The seed SeedCorpus generates with, so that the corpus is the same every run.
+const seedCorpusSeed = 1
+
+// CorpusAdder is what SeedCorpus adds strings to, e.g. a *testing.F. It keeps the testing package out of
+// programs using this one.
+type CorpusAdder interface {
+	Add(args ...interface{})
+}
+
+/*
+SeedCorpus adds n strings matching pattern to the seed corpus of a fuzz test, passed as f, e.g. to bootstrap a fuzz
+target expecting structured input. The pattern is parsed with default GeneratorArgs, like Generate, and strings
+are generated with a fixed seed, so the corpus is reproducible.
+*/
+func SeedCorpus(f CorpusAdder, pattern string, n int) error {
+	values, err := seedCorpusValues(pattern, n)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		f.Add(value)
+	}
+	return nil
+}
+
+// seedCorpusValues returns the strings SeedCorpus adds.
+func seedCorpusValues(pattern string, n int) ([]string, error) {
+	if n < 0 {
+		return nil, generatorError(nil, "n must not be negative, was %d", n)
+	}
+	seed := int64(seedCorpusSeed)
+	generator, err := NewGenerator(pattern, &GeneratorArgs{Seed: &seed})
+	if err != nil {
+		return nil, err
+	}
+	return generator.GenerateN(n), nil
+}
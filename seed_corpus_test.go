@@ -0,0 +1,89 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func FuzzSeedCorpus(f *testing.F) {
+	if err := SeedCorpus(f, `[a-z]{1,8}=[0-9]{1,4}`, 20); err != nil {
+		f.Fatal(err)
+	}
+	pattern := regexp.MustCompile(`^[a-z]{1,8}=[0-9]{1,4}$`)
+	f.Fuzz(func(t *testing.T, s string) {
+		if !pattern.MatchString(s) {
+			t.Skip("not structured input")
+		}
+		if parts := strings.Split(s, "="); len(parts) != 2 || parts[0]+"="+parts[1] != s {
+			t.Fatalf("%q should split in two", s)
+		}
+	})
+}
+
+func TestSeedCorpus(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Is reproducible", func(t *testing.T) {
+		t.Parallel()
+
+		a, err := seedCorpusValues(`[a-z]{1,8}`, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := seedCorpusValues(`[a-z]{1,8}`, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(a) != 10 || strings.Join(a, ",") != strings.Join(b, ",") {
+			t.Fatal("should be equal:", a, b)
+		}
+	})
+
+	t.Run("Adds to any corpus", func(t *testing.T) {
+		t.Parallel()
+
+		var corpus sliceCorpus
+		if err := SeedCorpus(&corpus, `[0-9]{3}`, 5); err != nil {
+			t.Fatal(err)
+		}
+		if len(corpus) != 5 {
+			t.Fatalf("should add 5 values, added %v", corpus)
+		}
+	})
+
+	t.Run("Forwards errors", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := seedCorpusValues(`a(`, 10); err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if _, err := seedCorpusValues(`a`, -1); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+// sliceCorpus collects the values SeedCorpus adds.
+type sliceCorpus []interface{}
+
+func (c *sliceCorpus) Add(args ...interface{}) {
+	*c = append(*c, args...)
+}
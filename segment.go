@@ -0,0 +1,45 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "regexp/syntax"
+
+// newSegmentedGenerator creates a generator for a concatenation that joins the strings generated for its parts
+// with args.SegmentSeparator.
+func newSegmentedGenerator(regexp *syntax.Regexp, args *GeneratorArgs) (*internalGenerator, error) {
+	enforceOp(regexp, syntax.OpConcat)
+
+	generators, err := newGenerators(regexp.Sub, args)
+	if err != nil {
+		return nil, generatorError(err, "error creating generators for concat pattern /%s/", regexp)
+	}
+
+	return &internalGenerator{
+		Name:   regexp.String(),
+		regexp: regexp,
+		args:   args,
+		GenerateFunc: func(state *generatorState) {
+			for i, generator := range generators {
+				if i > 0 {
+					state.out.WriteString(args.SegmentSeparator)
+				}
+				generator.GenerateFunc(state)
+			}
+		},
+	}, nil
+}
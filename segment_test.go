@@ -0,0 +1,58 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"testing"
+)
+
+func TestSegmentSeparator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Separates top-level parts", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Flags: syntax.Perl, SegmentSeparator: "\n"}
+		GeneratesStringMatching(t, args, `(foo)(bar)`, `^foo\nbar$`)
+		GeneratesStringMatching(t, args, `foo\d(bar|baz)`, `^foo\n\d\nba[rz]$`)
+		GeneratesStringMatching(t, args, `(a(b)c)d`, `^abc\nd$`)
+	})
+
+	t.Run("Leaves other expressions alone", func(t *testing.T) {
+		t.Parallel()
+
+		args := &GeneratorArgs{Flags: syntax.Perl, SegmentSeparator: "\n"}
+		GeneratesStringMatching(t, args, `foobar`, `^foobar$`)
+		GeneratesStringMatching(t, args, `foo|bar`, `^(foo|bar)$`)
+	})
+
+	t.Run("Reports the original expression", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`(foo)(bar)`, &GeneratorArgs{SegmentSeparator: ","})
+		compiled, err := generator.CompiledRegexp()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !compiled.MatchString("foobar") || regexp.MustCompile(`^foo,bar$`).FindString(generator.Generate()) == "" {
+			t.Fatal("should match")
+		}
+	})
+}
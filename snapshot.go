@@ -0,0 +1,80 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"encoding/binary"
+	"math/rand"
+)
+
+// Length of the state returned by SnapshotState.
+const snapshotLength = 8
+
+// SnapshotState returns the state of the generator's source, which RestoreState restores so that the generator
+// continues from the same point, e.g. in a later run of the program. It returns nil for generators without an
+// RngSource.
+func (gen *internalGenerator) SnapshotState() []byte {
+	if gen.args == nil || gen.args.source == nil {
+		return nil
+	}
+	snapshot := make([]byte, snapshotLength)
+	binary.BigEndian.PutUint64(snapshot, gen.args.source.getState())
+	return snapshot
+}
+
+// RestoreState restores the state of the generator's source from a snapshot returned by SnapshotState of a
+// generator for the same expression and args.
+func (gen *internalGenerator) RestoreState(snapshot []byte) error {
+	if gen.args == nil || gen.args.source == nil {
+		return generatorError(nil, "generator /%s/ has no RngSource to restore", gen)
+	}
+	if len(snapshot) != snapshotLength {
+		return generatorError(nil, "invalid snapshot: expected %d bytes, got %d", snapshotLength, len(snapshot))
+	}
+	state := binary.BigEndian.Uint64(snapshot)
+	if state == 0 {
+		// Not a state xorShift64Source can be in.
+		return generatorError(nil, "invalid snapshot: state is 0")
+	}
+	gen.args.source.setState(state)
+	return nil
+}
+
+/*
+Reset reseeds the generator's source in place, so that it generates the same strings as a generator created with
+Seed set to seed and the same args, without parsing the expression again. Generators without a source of their
+own, i.e. without an RngSource or with a Rand, get one, so that clones sharing their args and the Rand are
+unaffected. It must not be called while generating, unless the generator is Concurrent and already has an
+RngSource.
+*/
+func (gen *internalGenerator) Reset(seed int64) {
+	if gen.args == nil {
+		return
+	}
+	// Seeded like NewGenerator seeds from Seed.
+	seed = saltSeed(rand.NewSource(seed).Int63(), gen.args.Salt)
+	if gen.args.source != nil {
+		// Clones of generators with a source have their own args.
+		gen.args.source.Seed(seed)
+		return
+	}
+	args := *gen.args
+	args.Rand = nil
+	args.seed(seed)
+	gen.args = &args
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestSnapshotState(t *testing.T) {
+	t.Parallel()
+
+	generate10 := func(generator Generator) []string {
+		results := make([]string, 10)
+		for i := range results {
+			results[i] = generator.Generate()
+		}
+		return results
+	}
+
+	t.Run("Restoring repeats the sequence", func(t *testing.T) {
+		t.Parallel()
+
+		for _, concurrent := range []bool{false, true} {
+			generator, _ := NewGenerator(`[a-z]{3,8}(-[0-9]+)?`, &GeneratorArgs{
+				RngSource:  rand.NewSource(4),
+				Concurrent: concurrent,
+			})
+			generator.Generate()
+
+			snapshot := generator.SnapshotState()
+			first := generate10(generator)
+			if err := generator.RestoreState(snapshot); err != nil {
+				t.Fatal(err)
+			}
+			second := generate10(generator)
+			for i := range first {
+				if first[i] != second[i] {
+					t.Fatalf("string %d should be equal: %q, %q", i, first[i], second[i])
+				}
+			}
+		}
+	})
+
+	t.Run("Restoring in another generator", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`[a-z]{5}`, &GeneratorArgs{RngSource: rand.NewSource(4)})
+		other, _ := NewGenerator(`[a-z]{5}`, &GeneratorArgs{RngSource: rand.NewSource(5)})
+		if err := other.RestoreState(generator.SnapshotState()); err != nil {
+			t.Fatal(err)
+		}
+		if s, expected := other.Generate(), generator.Generate(); s != expected {
+			t.Fatalf("should be equal: %q, %q", s, expected)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		unseeded, _ := NewGenerator(`a`, nil)
+		if unseeded.SnapshotState() != nil {
+			t.Fatal("should be nil")
+		}
+		if err := unseeded.RestoreState(make([]byte, 8)); err == nil {
+			t.Fatal("err should not be nil")
+		}
+
+		generator, _ := NewGenerator(`a`, &GeneratorArgs{RngSource: rand.NewSource(4)})
+		for _, snapshot := range [][]byte{nil, {1, 2, 3}, make([]byte, 8)} {
+			if err := generator.RestoreState(snapshot); err == nil {
+				t.Fatalf("err should not be nil for %v", snapshot)
+			}
+		}
+	})
+}
+
+func TestReset(t *testing.T) {
+	t.Parallel()
+
+	generate10 := func(generator Generator) []string {
+		results := make([]string, 10)
+		for i := range results {
+			results[i] = generator.Generate()
+		}
+		return results
+	}
+
+	t.Run("Repeats the sequence", func(t *testing.T) {
+		t.Parallel()
+
+		for _, concurrent := range []bool{false, true} {
+			seed := int64(3)
+			generator, _ := NewGenerator(`[a-z]{3,8}(-[0-9]+)?`, &GeneratorArgs{
+				Seed:       &seed,
+				Salt:       []byte("tenant"),
+				Concurrent: concurrent,
+			})
+			expected := generate10(generator)
+			generator.Reset(seed)
+			if actual := generate10(generator); fmt.Sprint(actual) != fmt.Sprint(expected) {
+				t.Fatalf("should be equal: %q, %q", actual, expected)
+			}
+		}
+	})
+
+	t.Run("Seeds generators without a source", func(t *testing.T) {
+		t.Parallel()
+
+		seed := int64(9)
+		seeded, _ := NewGenerator(`[a-z]{5}`, &GeneratorArgs{Seed: &seed})
+		unseeded, _ := NewGenerator(`[a-z]{5}`, nil)
+		unseeded.Reset(seed)
+		if actual, expected := generate10(unseeded), generate10(seeded); fmt.Sprint(actual) != fmt.Sprint(expected) {
+			t.Fatalf("should be equal: %q, %q", actual, expected)
+		}
+		if unseeded.SnapshotState() == nil {
+			t.Fatal("should not be nil")
+		}
+	})
+
+	t.Run("Leaves shared sources alone", func(t *testing.T) {
+		t.Parallel()
+
+		seed := int64(4)
+		rng := rand.New(rand.NewSource(1))
+		withRand, _ := NewGenerator(`[a-z]{5}`, &GeneratorArgs{Rand: rng})
+		clone := withRand.Clone()
+		withRand.Reset(seed)
+		if next, expected := rng.Int63(), rand.New(rand.NewSource(1)).Int63(); next != expected {
+			t.Fatal("Rand should not be reseeded")
+		}
+		seeded, _ := NewGenerator(`[a-z]{5}`, &GeneratorArgs{Seed: &seed})
+		if actual, expected := generate10(withRand), generate10(seeded); fmt.Sprint(actual) != fmt.Sprint(expected) {
+			t.Fatalf("should be equal: %q, %q", actual, expected)
+		}
+
+		unseeded, _ := NewGenerator(`[a-z]{5}`, nil)
+		sibling := unseeded.Clone()
+		unseeded.Reset(seed)
+		if sibling.SnapshotState() != nil {
+			t.Fatal("clone should keep the default source")
+		}
+		if clone.SnapshotState() != nil {
+			t.Fatal("clone should keep using Rand")
+		}
+	})
+}
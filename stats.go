@@ -0,0 +1,122 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "sync"
+
+// Stats counts the choices a generator made while generating, for checking that a sample exercises every part
+// of an expression. Nodes are keyed by their expression, as formatted by syntax.Regexp.String, like
+// AlternationWeights, so identical nodes share counts.
+type Stats struct {
+	// Number of times each branch of an alternation was generated, by branch index.
+	Branches map[string][]int
+	// Number of times each count of instances was generated for a repeat expression, by count.
+	Repeats map[string]map[int]int
+}
+
+// statsCollector accumulates Stats for a generator and its clones. A nil collector records nothing.
+type statsCollector struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+func newStatsCollector() *statsCollector {
+	c := &statsCollector{}
+	c.reset()
+	return c
+}
+
+func (c *statsCollector) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats = Stats{Branches: map[string][]int{}, Repeats: map[string]map[int]int{}}
+}
+
+// branch records that the index-th of n branches of the alternation name was generated.
+func (c *statsCollector) branch(name string, index, n int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := c.stats.Branches[name]
+	if counts == nil {
+		counts = make([]int, n)
+		c.stats.Branches[name] = counts
+	}
+	counts[index]++
+}
+
+// repeat records that count instances of the repeat expression name were generated.
+func (c *statsCollector) repeat(name string, count int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := c.stats.Repeats[name]
+	if counts == nil {
+		counts = map[int]int{}
+		c.stats.Repeats[name] = counts
+	}
+	counts[count]++
+}
+
+// snapshot returns a copy of the stats collected so far.
+func (c *statsCollector) snapshot() Stats {
+	stats := Stats{Branches: map[string][]int{}, Repeats: map[string]map[int]int{}}
+	if c == nil {
+		return stats
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, counts := range c.stats.Branches {
+		stats.Branches[name] = append([]int(nil), counts...)
+	}
+	for name, counts := range c.stats.Repeats {
+		copied := make(map[int]int, len(counts))
+		for count, hits := range counts {
+			copied[count] = hits
+		}
+		stats.Repeats[name] = copied
+	}
+	return stats
+}
+
+/*
+Stats returns the choices made by every string generated since the generator was created, or since ResetStats was
+last called. It is empty unless GeneratorArgs.CollectStats is set. Clones share their stats with the generator
+they were cloned from.
+*/
+func (gen *internalGenerator) Stats() Stats {
+	return gen.statsCollector().snapshot()
+}
+
+// ResetStats clears the stats returned by Stats.
+func (gen *internalGenerator) ResetStats() {
+	if stats := gen.statsCollector(); stats != nil {
+		stats.reset()
+	}
+}
+
+func (gen *internalGenerator) statsCollector() *statsCollector {
+	if gen.args == nil {
+		return nil
+	}
+	return gen.args.stats
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Counts branches and repeats", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(a|b|c)x{1,3}`, &GeneratorArgs{CollectStats: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		generator.GenerateN(SampleSize)
+
+		stats := generator.Stats()
+		branches := stats.Branches[`[a-c]`]
+		if branches != nil {
+			t.Fatal("character classes should not count as alternations:", branches)
+		}
+		repeats := stats.Repeats[`x{1,3}`]
+		total := 0
+		for count, hits := range repeats {
+			if count < 1 || count > 3 {
+				t.Fatal("repeat count out of range:", count)
+			}
+			total += hits
+		}
+		if total != SampleSize || len(repeats) != 3 {
+			t.Fatal("should count every repeat count:", repeats)
+		}
+	})
+
+	t.Run("Counts every alternation branch", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`foo|bar|(baz)?`, &GeneratorArgs{CollectStats: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		generator.GenerateN(SampleSize)
+
+		stats := generator.Stats()
+		branches := stats.Branches[`foo|bar|(baz)?`]
+		if len(branches) != 3 || branches[0]+branches[1]+branches[2] != SampleSize {
+			t.Fatal("should count every branch:", branches)
+		}
+		for i, hits := range branches {
+			if hits == 0 {
+				t.Fatal("branch never counted:", i)
+			}
+		}
+		if quest := stats.Repeats[`(baz)?`]; quest[0]+quest[1] != branches[2] {
+			t.Fatal("should count the optional group when its branch is generated:", quest, branches)
+		}
+	})
+
+	t.Run("Accumulates until reset", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`a*`, &GeneratorArgs{CollectStats: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		countRepeats := func() int {
+			total := 0
+			for _, hits := range generator.Stats().Repeats[`a*`] {
+				total += hits
+			}
+			return total
+		}
+
+		generator.Generate()
+		generator.Generate()
+		if n := countRepeats(); n != 2 {
+			t.Fatal("should be equal:", n, 2)
+		}
+		generator.ResetStats()
+		if n := countRepeats(); n != 0 {
+			t.Fatal("should be equal:", n, 0)
+		}
+		generator.Generate()
+		if n := countRepeats(); n != 1 {
+			t.Fatal("should be equal:", n, 1)
+		}
+	})
+
+	t.Run("Empty unless collecting", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`a|b`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		generator.GenerateN(10)
+		generator.ResetStats()
+		if stats := generator.Stats(); len(stats.Branches) != 0 || len(stats.Repeats) != 0 {
+			t.Fatal("should be empty:", stats)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"context"
+	"io"
+)
+
+// Channel returns a channel that receives generated strings until ctx is cancelled, at which point the channel is
+// closed. buffer is the capacity of the channel; the producing goroutine blocks while it is full.
+func (gen *internalGenerator) Channel(ctx context.Context, buffer int) <-chan string {
+	ch := make(chan string, buffer)
+	go func() {
+		defer close(ch)
+		var previous *string
+		for {
+			s := gen.nextInStream(previous, gen.Generate)
+			select {
+			case ch <- s:
+				previous = &s
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+/*
+Stream returns an unbuffered channel of generated strings, for ranging over until ctx is cancelled, at which point
+the channel is closed and the goroutine filling it exits. It is Channel(ctx, 0).
+
+Strings are generated one at a time from the generator's own source, so with a seeded RngSource a stream is
+reproducible. The channel may be read from several goroutines, but strings are still generated serially.
+*/
+func (gen *internalGenerator) Stream(ctx context.Context) <-chan string {
+	return gen.Channel(ctx, 0)
+}
+
+/*
+Reader returns a reader of an endless stream of generated strings, with sep between them, e.g. "\n" to read one
+string per line. Strings are split across reads as the buffers passed to Read require. Reads only fail, with
+io.ErrNoProgress, if sep is empty and maxEmptyReadStrings strings in a row are too, e.g. for `a{0}`. Like Stream,
+strings are generated one at a time from the generator's own source. The reader is not safe for concurrent use.
+*/
+func (gen *internalGenerator) Reader(sep string) io.Reader {
+	return &streamReader{gen: gen, sep: sep}
+}
+
+// streamReader is the reader returned by Reader.
+type streamReader struct {
+	gen *internalGenerator
+	sep string
+	// The last string generated, or nil before the first one.
+	previous *string
+	// What is left to read of the last string generated, and the separator before it.
+	pending string
+}
+
+// Number of empty strings in a row Reader generates with an empty separator before giving up.
+const maxEmptyReadStrings = 100
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	n, empty := 0, 0
+	for n < len(p) {
+		if r.pending == "" {
+			if empty == maxEmptyReadStrings {
+				if n > 0 {
+					return n, nil
+				}
+				return 0, io.ErrNoProgress
+			}
+			s := r.gen.nextInStream(r.previous, r.gen.Generate)
+			if r.previous != nil {
+				r.pending = r.sep
+			}
+			r.pending += s
+			r.previous = &s
+			if r.pending == "" {
+				empty++
+			}
+			continue
+		}
+		copied := copy(p[n:], r.pending)
+		r.pending = r.pending[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+// GenerateUntil generates up to max strings, passing each to stop, until stop returns true. It returns the strings
+// generated, including the one stop returned true for, and whether stop returned true.
+func (gen *internalGenerator) GenerateUntil(stop func(s string) bool, max int) ([]string, bool) {
+	var generated []string
+	var previous *string
+	for len(generated) < max {
+		s := gen.nextInStream(previous, gen.Generate)
+		generated = append(generated, s)
+		if stop(s) {
+			return generated, true
+		}
+		previous = &s
+	}
+	return generated, false
+}
+
+// Number of times a string equal to the previous one in a stream is regenerated when NoAdjacentDuplicates is set.
+const maxAdjacentDuplicateRetries = 10
+
+// nextInStream generates the next string of a stream with generate, e.g. gen.Generate. previous is the last string
+// in the stream, or nil if this is the first one.
+func (gen *internalGenerator) nextInStream(previous *string, generate func() string) string {
+	s := generate()
+	if previous == nil || gen.args == nil || !gen.args.NoAdjacentDuplicates {
+		return s
+	}
+	for i := 0; i < maxAdjacentDuplicateRetries && s == *previous; i++ {
+		s = generate()
+	}
+	return s
+}
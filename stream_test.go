@@ -0,0 +1,256 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChannel(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Emits matching strings", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("[ab]{5}", nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch := generator.Channel(ctx, 4)
+		for i := 0; i < SampleSize; i++ {
+			s := <-ch
+			if matched, _ := regexp.MatchString("^[ab]{5}$", s); !matched {
+				t.Fatalf("%q does not match", s)
+			}
+		}
+	})
+
+	t.Run("Closes when cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("a", nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		ch := generator.Channel(ctx, 0)
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					return
+				}
+			case <-timeout:
+				t.Fatal("channel was not closed")
+			}
+		}
+	})
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Reproducible with a seed", func(t *testing.T) {
+		t.Parallel()
+
+		args := func() *GeneratorArgs {
+			return &GeneratorArgs{RngSource: rand.NewSource(5)}
+		}
+		streaming, _ := NewGenerator("[a-z]{6}", args())
+		generating, _ := NewGenerator("[a-z]{6}", args())
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		i := 0
+		for s := range streaming.Stream(ctx) {
+			if expected := generating.Generate(); s != expected {
+				t.Fatalf("string %d should be %q, was %q", i, expected, s)
+			}
+			if i++; i == 100 {
+				break
+			}
+		}
+	})
+
+	t.Run("Closes when cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("a", nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := generator.Stream(ctx)
+		<-ch
+		cancel()
+
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					return
+				}
+			case <-timeout:
+				t.Fatal("channel was not closed")
+			}
+		}
+	})
+}
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Lines match", func(t *testing.T) {
+		t.Parallel()
+
+		gen, _ := NewGenerator(`[a-z]{1,5}-[0-9]{2}`, &GeneratorArgs{RngSource: rand.NewSource(1)})
+		// Small reads, so strings are split across them.
+		data, err := io.ReadAll(io.LimitReader(bufio.NewReaderSize(gen.Reader("\n"), 16), 1000))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != 1000 {
+			t.Fatalf("should read 1000 bytes, read %d", len(data))
+		}
+		lines := strings.Split(string(data), "\n")
+		matcher := regexp.MustCompile(`^[a-z]{1,5}-[0-9]{2}$`)
+		// The last line may be cut short.
+		for _, line := range lines[:len(lines)-1] {
+			if !matcher.MatchString(line) {
+				t.Fatalf("line %q should match", line)
+			}
+		}
+	})
+
+	t.Run("Same as Generate", func(t *testing.T) {
+		t.Parallel()
+
+		gen, _ := NewGenerator(`[a-z]{1,5}`, &GeneratorArgs{RngSource: rand.NewSource(2)})
+		expected, _ := NewGenerator(`[a-z]{1,5}`, &GeneratorArgs{RngSource: rand.NewSource(2)})
+		reader := gen.Reader(", ")
+		buf := make([]byte, 3)
+		var read strings.Builder
+		for read.Len() < 200 {
+			n, err := reader.Read(buf)
+			if err != nil || n != len(buf) {
+				t.Fatalf("should fill the buffer, read %d, %v", n, err)
+			}
+			read.Write(buf[:n])
+		}
+		strs := expected.GenerateN(200)
+		if s := strings.Join(strs, ", ")[:read.Len()]; read.String() != s {
+			t.Fatalf("read %q, expected %q", read.String(), s)
+		}
+	})
+
+	t.Run("Only empty strings", func(t *testing.T) {
+		t.Parallel()
+
+		gen, _ := NewGenerator(`a{0}`, nil)
+		if _, err := gen.Reader("").Read(make([]byte, 1)); err != io.ErrNoProgress {
+			t.Fatalf("err should be io.ErrNoProgress, was %v", err)
+		}
+	})
+}
+
+func TestNoAdjacentDuplicates(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Avoids repeats", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("[a-d]", &GeneratorArgs{NoAdjacentDuplicates: true})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch := generator.Channel(ctx, 0)
+		previous := <-ch
+		for i := 0; i < SampleSize; i++ {
+			s := <-ch
+			if s == previous {
+				t.Fatalf("%q repeated", s)
+			}
+			previous = s
+		}
+	})
+
+	t.Run("Avoids repeats in GenerateN", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("[a-d]", &GeneratorArgs{NoAdjacentDuplicates: true})
+		results := generator.GenerateN(SampleSize)
+		for i := 1; i < len(results); i++ {
+			if results[i] == results[i-1] {
+				t.Fatalf("%q repeated", results[i])
+			}
+		}
+	})
+
+	t.Run("Gives up on single-string expressions", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("a", &GeneratorArgs{NoAdjacentDuplicates: true})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ch := generator.Channel(ctx, 0)
+		if <-ch != "a" || <-ch != "a" {
+			t.Fatal("should be a")
+		}
+	})
+}
+
+func TestGenerateUntil(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Stops when stop returns true", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`[a-c]{2}`, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		generated, stopped := generator.GenerateUntil(func(s string) bool {
+			return strings.Contains(s, "cc")
+		}, 10000)
+		if !stopped {
+			t.Fatal("should have stopped")
+		}
+		for i, s := range generated {
+			if (s == "cc") != (i == len(generated)-1) {
+				t.Fatalf("should stop right after generating cc, generated %q", generated)
+			}
+		}
+	})
+
+	t.Run("Generates at most max", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`a`, nil)
+		generated, stopped := generator.GenerateUntil(func(string) bool { return false }, 5)
+		if stopped || len(generated) != 5 {
+			t.Fatalf("should generate 5 strings without stopping, generated %q", generated)
+		}
+	})
+}
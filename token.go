@@ -0,0 +1,83 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Maximum number of strings a token can say were generated from its seed. GenerateFromToken rejects tokens with
+// more, so that a token from an untrusted source can't make it generate forever, and GenerateSatisfyingWithToken
+// fails rather than return one.
+const MaxTokenAttempts = 1 << 20
+
+// GenerateWithToken generates a string, and returns it with a token GenerateFromToken reproduces it from.
+func (gen *internalGenerator) GenerateWithToken() (value, token string) {
+	seed := gen.newState().rng.Uint64()
+	return gen.generateString(newSeededState(seed)), formatToken(seed, 1)
+}
+
+// GenerateFromToken reproduces the string a token was returned with, e.g. by GenerateWithToken or
+// GenerateSatisfyingWithToken.
+func (gen *internalGenerator) GenerateFromToken(token string) (string, error) {
+	if gen.regexp == nil {
+		return "", generatorError(nil, "generator /%s/ has no source expression to reproduce strings from", gen.Name)
+	}
+	seed, attempts, err := parseToken(token)
+	if err != nil {
+		return "", err
+	}
+
+	state := newSeededState(seed)
+	var result string
+	for i := 0; i < attempts; i++ {
+		result = gen.generateString(state)
+	}
+	return result, nil
+}
+
+func newSeededState(seed uint64) *generatorState {
+	return &generatorState{rng: rand.New(newXorShift64Source(int64(seed)))}
+}
+
+func formatToken(seed uint64, attempts int) string {
+	return fmt.Sprintf("%016x.%d", seed, attempts)
+}
+
+func parseToken(token string) (seed uint64, attempts int, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || len(parts[0]) != 16 {
+		return 0, 0, generatorError(nil, "invalid token %q", token)
+	}
+
+	seed, err = strconv.ParseUint(parts[0], 16, 64)
+	if err != nil {
+		return 0, 0, generatorError(err, "invalid token %q", token)
+	}
+	attempts, err = strconv.Atoi(parts[1])
+	if err != nil || attempts < 1 {
+		return 0, 0, generatorError(err, "invalid token %q", token)
+	}
+	if attempts > MaxTokenAttempts {
+		return 0, 0, generatorError(nil, "invalid token %q: more than %d attempts", token, MaxTokenAttempts)
+	}
+	return seed, attempts, nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"strings"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Round trip", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(\w{1,8})-([a-f\d]{4})?`, &GeneratorArgs{Flags: syntax.Perl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A separate generator for the same expression, as if in a later run.
+		reproducer, _ := NewGenerator(`(\w{1,8})-([a-f\d]{4})?`, &GeneratorArgs{Flags: syntax.Perl})
+
+		for i := 0; i < SampleSize; i++ {
+			value, token := generator.GenerateWithToken()
+			reproduced, err := reproducer.GenerateFromToken(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reproduced != value {
+				t.Fatalf("token %s reproduced %q, should be %q", token, reproduced, value)
+			}
+		}
+	})
+
+	t.Run("Round trip through filters", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`[a-z]{1,3}`, nil)
+		sawRetries := false
+		for i := 0; i < 100; i++ {
+			value, token, err := generator.GenerateSatisfyingWithToken(func(s string) bool {
+				return strings.HasPrefix(s, "q")
+			}, RetryPolicy{MaxAttempts: 1000})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sawRetries = sawRetries || !strings.HasSuffix(token, ".1")
+
+			reproduced, err := generator.GenerateFromToken(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reproduced != value {
+				t.Fatalf("token %s reproduced %q, should be %q", token, reproduced, value)
+			}
+		}
+		if !sawRetries {
+			t.Fatal("some values should have needed retries")
+		}
+	})
+
+	t.Run("Pools cycle on", func(t *testing.T) {
+		t.Parallel()
+
+		pools := map[string][]string{"env": {"dev", "prod"}}
+		inOrder, _ := NewGenerator(`(?P<env>x)-[0-9]{6}`, &GeneratorArgs{Flags: syntax.Perl, GroupValuePools: pools})
+		s, token := inOrder.GenerateWithToken()
+		if reproduced, _ := inOrder.GenerateFromToken(token); reproduced != "prod"+strings.TrimPrefix(s, "dev") {
+			t.Fatalf("%q should be reproduced with the next pool value, was %q", s, reproduced)
+		}
+
+		random, _ := NewGenerator(`(?P<env>x)-[0-9]{6}`, &GeneratorArgs{
+			Flags:                 syntax.Perl,
+			GroupValuePools:       pools,
+			RandomGroupValuePools: true,
+		})
+		for i := 0; i < 10; i++ {
+			s, token := random.GenerateWithToken()
+			if reproduced, _ := random.GenerateFromToken(token); reproduced != s {
+				t.Fatalf("%q should be reproduced, was %q", s, reproduced)
+			}
+		}
+	})
+
+	t.Run("Invalid tokens", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`a`, nil)
+		for _, token := range []string{"", "abc", "0123456789abcdef", "0123456789abcdef.0", "0123456789abcdeg.1", "123.1",
+			"0000000000000001.2000000000"} {
+			if _, err := generator.GenerateFromToken(token); err == nil {
+				t.Fatalf("%q should be invalid", token)
+			}
+		}
+	})
+
+	t.Run("Scheduled generators", func(t *testing.T) {
+		t.Parallel()
+
+		a, _ := NewGenerator(`a`, nil)
+		scheduled, err := NewScheduledGenerator([]Generator{a}, func(int) int { return 0 })
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, token := scheduled.GenerateWithToken()
+		if _, err := scheduled.GenerateFromToken(token); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"sort"
+)
+
+// floatWeightedChoice returns a function choosing an index of weights at random, in proportion to its weight. The
+// weights must not be negative, and at least one must be positive.
+func floatWeightedChoice(weights []float64) func(rng *rand.Rand) int {
+	cumulative := make([]float64, len(weights))
+	total := 0.0
+	for i, weight := range weights {
+		total += weight
+		cumulative[i] = total
+	}
+	return func(rng *rand.Rand) int {
+		x := rng.Float64() * total
+		i := sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > x })
+		if i == len(cumulative) {
+			// Rounding error.
+			i--
+		}
+		return i
+	}
+}
+
+// uniformRepeatChoice returns a function choosing a number of repetitions between min and max of a sub-expression
+// generating 2^log2Sub strings, in proportion to the number of strings each number of repetitions generates.
+func uniformRepeatChoice(log2Sub float64, min, max int) func(rng *rand.Rand) int {
+	if math.IsInf(log2Sub, -1) {
+		// Only no repetitions generate anything.
+		return func(*rand.Rand) int { return min }
+	}
+
+	// Relative to the weight of max repetitions, so that the largest is 1 rather than overflowing.
+	weights := make([]float64, max-min+1)
+	for i := range weights {
+		weights[i] = math.Exp2(float64(i-(max-min)) * log2Sub)
+	}
+	choose := floatWeightedChoice(weights)
+	return func(rng *rand.Rand) int {
+		return min + choose(rng)
+	}
+}
+
+// checkAlternationWeights returns an error if a key of AlternationWeights isn't an alternation of regexp, or doesn't
+// have a weight for each of its branches.
+func (a *GeneratorArgs) checkAlternationWeights(regexp *syntax.Regexp) error {
+	if len(a.AlternationWeights) == 0 {
+		return nil
+	}
+
+	nodes := map[string]*syntax.Regexp{}
+	var alternations []string
+	walkRegexp(regexp, func(sub *syntax.Regexp) {
+		if sub.Op == syntax.OpAlternate {
+			nodes[sub.String()] = sub
+			alternations = append(alternations, sub.String())
+		}
+	})
+
+	for alternation, weights := range a.AlternationWeights {
+		node, ok := nodes[alternation]
+		if !ok {
+			return generatorError(nil, "AlternationWeights[%q] is not an alternation of /%s/, which has %q",
+				alternation, regexp, alternations)
+		}
+		if len(weights) != len(node.Sub) {
+			return generatorError(nil, "AlternationWeights[%q] has %d weights for %d branches",
+				alternation, len(weights), len(node.Sub))
+		}
+		total := 0.0
+		for i, sub := range node.Sub {
+			if unmatchable(sub) == nil {
+				total += weights[i]
+			}
+		}
+		if total == 0 {
+			return generatorError(nil, "AlternationWeights[%q] must have a positive weight for a branch that can match",
+				alternation)
+		}
+	}
+	return nil
+}
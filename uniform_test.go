@@ -0,0 +1,156 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"testing"
+)
+
+func TestUniform(t *testing.T) {
+	t.Parallel()
+
+	// chiSquare returns the chi-square statistic of counts against the expected proportions.
+	chiSquare := func(counts map[string]int, expected map[string]float64, n int) float64 {
+		statistic := 0.0
+		for s, p := range expected {
+			e := p * float64(n)
+			statistic += (float64(counts[s]) - e) * (float64(counts[s]) - e) / e
+		}
+		return statistic
+	}
+
+	t.Run("Weighs branches by their number of strings", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`a|b[0-9]|c[0-9]{2}`, &GeneratorArgs{Uniform: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		const n = 11100
+		counts := map[string]int{}
+		for i := 0; i < n; i++ {
+			counts[generator.Generate()[:1]]++
+		}
+		// 1, 10 and 100 strings. 2 degrees of freedom, p = 0.001.
+		expected := map[string]float64{"a": 1.0 / 111, "b": 10.0 / 111, "c": 100.0 / 111}
+		if statistic := chiSquare(counts, expected, n); statistic > 13.8 {
+			t.Fatalf("frequencies %v aren't proportional to branch cardinalities, chi-square %v", counts, statistic)
+		}
+	})
+
+	t.Run("Weighs repetitions by their number of strings", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`[ab]{0,2}`, &GeneratorArgs{Uniform: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		const n = 7000
+		counts := map[string]int{}
+		for i := 0; i < n; i++ {
+			counts[generator.Generate()]++
+		}
+		// Every one of the 7 strings equally likely. 6 degrees of freedom, p = 0.001.
+		expected := map[string]float64{}
+		for _, s := range []string{"", "a", "b", "aa", "ab", "ba", "bb"} {
+			expected[s] = 1.0 / 7
+		}
+		if statistic := chiSquare(counts, expected, n); statistic > 22.5 {
+			t.Fatalf("frequencies %v aren't uniform, chi-square %v", counts, statistic)
+		}
+	})
+
+	t.Run("Handles huge counts", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(x|.{20})`, &GeneratorArgs{Uniform: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			if s := generator.Generate(); s == "x" {
+				t.Fatal("should nearly never generate the single-string branch")
+			}
+		}
+
+		if _, err := NewGenerator(`(.*)*`, &GeneratorArgs{Uniform: true}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestAlternationWeights(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Weighs branches", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(common|rare)-(x|yy)`, &GeneratorArgs{
+			AlternationWeights: map[string][]float64{"common|rare": {9, 1}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		const n = 10000
+		counts := map[string]int{}
+		for i := 0; i < n; i++ {
+			s := generator.Generate()
+			counts[s[:1]]++
+			counts[s[len(s)-1:]]++
+		}
+		if common := float64(counts["c"]) / n; common < 0.87 || common > 0.93 {
+			t.Fatalf("should generate common 90%% of the time, was %v", common)
+		}
+		// Unweighted alternations are unaffected.
+		if x := float64(counts["x"]) / n; x < 0.47 || x > 0.53 {
+			t.Fatalf("should generate x half of the time, was %v", x)
+		}
+	})
+
+	t.Run("Zero weights", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`never|always|nope`, &GeneratorArgs{
+			AlternationWeights: map[string][]float64{"never|always|nope": {0, 1, 0}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			if s := generator.Generate(); s != "always" {
+				t.Fatal("should be equal:", s, "always")
+			}
+		}
+	})
+
+	t.Run("Invalid weights", func(t *testing.T) {
+		t.Parallel()
+
+		for _, weights := range []map[string][]float64{
+			{"common|rare": {1}},
+			{"common|rare": {1, 2, 3}},
+			{"common|rare": {-1, 2}},
+			{"common|rare": {0, 0}},
+			{"rare|common": {1, 2}},
+		} {
+			if _, err := NewGenerator(`common|rare`, &GeneratorArgs{AlternationWeights: weights}); err == nil {
+				t.Fatalf("%v: err should not be nil", weights)
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"unicode"
+)
+
+// Runes encoded in 1, 2, 3 and 4 bytes of UTF-8.
+var utf8WidthRanges = [4][2]rune{{0, 0x7F}, {0x80, 0x7FF}, {0x800, 0xFFFF}, {0x10000, unicode.MaxRune}}
+
+// createUTF8MixGenerator returns a generator that picks runes from charClass with the UTF-8 widths weighted
+// by args.UTF8ByteLengthMix, or nil if charClass has no runes of any width with a non-zero weight.
+func createUTF8MixGenerator(name string, charClass *tCharClass, args *GeneratorArgs) *internalGenerator {
+	var classes []*tCharClass
+	var cumulativeWeights []float64
+	total := 0.0
+	for i, widthRange := range utf8WidthRanges {
+		class := charClass.intersect(widthRange[0], widthRange[1])
+		if class == nil || args.UTF8ByteLengthMix[i] == 0 {
+			continue
+		}
+		total += args.UTF8ByteLengthMix[i]
+		classes = append(classes, class)
+		cumulativeWeights = append(cumulativeWeights, total)
+	}
+	if classes == nil {
+		return nil
+	}
+
+	return &internalGenerator{Name: name, GenerateFunc: func(state *generatorState) {
+		p := state.rng.Float64() * total
+		class := classes[len(classes)-1]
+		for i, weight := range cumulativeWeights {
+			if p < weight {
+				class = classes[i]
+				break
+			}
+		}
+
+		r := class.GetRuneAt(state.rng.Int31n(class.TotalSize))
+		state.out.WriteRune(args.DigitScript.convert(r))
+	}}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"math"
+	"regexp/syntax"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestUTF8ByteLengthMix(t *testing.T) {
+	t.Parallel()
+
+	histogram := func(t *testing.T, pattern string, args *GeneratorArgs, samples int) (counts [5]int) {
+		generator, err := NewGenerator(pattern, args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < samples; i++ {
+			for _, r := range generator.Generate() {
+				counts[utf8.RuneLen(r)]++
+			}
+		}
+		return counts
+	}
+
+	t.Run("Follows the mix", func(t *testing.T) {
+		t.Parallel()
+
+		const samples = 8000
+		for _, pattern := range []string{`[^a]`, `(?s).`} {
+			counts := histogram(t, pattern, &GeneratorArgs{
+				Flags:             syntax.Perl,
+				UTF8ByteLengthMix: [4]float64{1, 1, 1, 1},
+			}, samples)
+
+			for width := 1; width <= 4; width++ {
+				// Within 5 standard deviations of samples/4.
+				if math.Abs(float64(counts[width])-samples/4) > 5*math.Sqrt(samples*0.25*0.75) {
+					t.Fatalf("/%s/ should generate each width about equally often, was %v", pattern, counts[1:])
+				}
+			}
+		}
+	})
+
+	t.Run("Only weighted widths", func(t *testing.T) {
+		t.Parallel()
+
+		counts := histogram(t, `[a-zé€𝄞]{8}`, &GeneratorArgs{UTF8ByteLengthMix: [4]float64{0, 1, 0, 1}}, SampleSize)
+		if counts[1] != 0 || counts[3] != 0 || counts[2] == 0 || counts[4] == 0 {
+			t.Fatalf("should only generate 2 and 4 byte runes, was %v", counts[1:])
+		}
+	})
+
+	t.Run("Classes without weighted widths are uniform", func(t *testing.T) {
+		t.Parallel()
+
+		GeneratesStringMatching(t, &GeneratorArgs{UTF8ByteLengthMix: [4]float64{0, 0, 0, 1}}, `[a-z]`, `^[a-z]$`)
+	})
+
+	t.Run("Rejects invalid weights", func(t *testing.T) {
+		t.Parallel()
+
+		for _, mix := range [][4]float64{{-1, 1, 1, 1}, {math.NaN(), 0, 0, 0}, {math.Inf(1), 0, 0, 0}} {
+			if _, err := NewGenerator(`.`, &GeneratorArgs{UTF8ByteLengthMix: mix}); err == nil {
+				t.Fatalf("%v should be rejected", mix)
+			}
+		}
+	})
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+// newValidatingGenerator wraps generator to check the strings it generates match its expression, regenerating those
+// that don't up to retries times, and failing with ErrInvalidOutput if the last one still doesn't. It returns an
+// error if the expression can't be compiled to check them. Strings of padded expressions, e.g. `\Bfoo`, only
+// contain a match, so they are checked for one rather than for matching as a whole.
+func newValidatingGenerator(generator *internalGenerator, retries int, padded bool) (*internalGenerator, error) {
+	compile := generator.fullRegexp
+	if padded {
+		compile = generator.CompiledRegexp
+	}
+	expression, err := compile()
+	if err != nil {
+		return nil, generatorError(err, "ValidateOutput can't check strings generated from /%s/", generator)
+	}
+
+	return &internalGenerator{
+		Name:   generator.Name,
+		regexp: generator.regexp,
+		args:   generator.args,
+		GenerateFunc: func(state *generatorState) {
+			s := generator.generateString(state)
+			for i := 0; i < retries && !state.cancelled() && !expression.MatchString(s); i++ {
+				// Only record the groups of the string returned.
+				state.resetCaptures()
+				s = generator.generateString(state)
+			}
+			if !state.cancelled() && !expression.MatchString(s) {
+				state.fail(generatorError(ErrInvalidOutput, "generated %q, which doesn't match /%s/, in %d attempts", s,
+					generator, retries+1))
+			}
+			state.out.WriteString(s)
+		},
+	}, nil
+}
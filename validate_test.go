@@ -0,0 +1,151 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"errors"
+	"regexp/syntax"
+	"testing"
+)
+
+func TestValidateOutput(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Passes matching strings", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`(\w+)@[a-z]{2,5}\.(com|org)`, &GeneratorArgs{
+			Flags:          syntax.Perl,
+			ValidateOutput: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < SampleSize; i++ {
+			if _, err := generator.GenerateE(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	t.Run("Fails strings a handler broke", func(t *testing.T) {
+		t.Parallel()
+
+		generator, err := NewGenerator(`id-(\d{3})`, &GeneratorArgs{
+			Flags:          syntax.Perl,
+			ValidateOutput: true,
+			CaptureGroupHandler: func(index int, name string, group *syntax.Regexp, generator Generator,
+				args *GeneratorArgs) string {
+				return "12"
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := generator.GenerateE(); !errors.Is(err, ErrInvalidOutput) {
+			t.Fatal("err should be ErrInvalidOutput:", err)
+		}
+		if s := generator.Generate(); s != "id-12" {
+			t.Fatal("should be equal:", s, "id-12")
+		}
+	})
+
+	t.Run("Passes padded expressions", func(t *testing.T) {
+		t.Parallel()
+
+		for _, pattern := range []string{`\Bfoo`, `-\b`} {
+			generator, err := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, ValidateOutput: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < SampleSize; i++ {
+				if _, err := generator.GenerateE(); err != nil {
+					t.Fatalf("/%s/: %v", pattern, err)
+				}
+			}
+		}
+	})
+
+	t.Run("Rejects expressions it can't check", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewGenerator(`(a)\1`, &GeneratorArgs{ValidateOutput: true}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
+
+func TestRetryUntilValid(t *testing.T) {
+	t.Parallel()
+
+	flakyGenerator := func(t *testing.T, retries, failures int) Generator {
+		calls := 0
+		generator, err := NewGenerator(`id-(\d{3})`, &GeneratorArgs{
+			Flags:           syntax.Perl,
+			ValidateOutput:  true,
+			RetryUntilValid: retries,
+			CaptureGroupHandler: func(index int, name string, group *syntax.Regexp, generator Generator,
+				args *GeneratorArgs) string {
+				calls++
+				if calls%(failures+1) != 0 {
+					return "oops"
+				}
+				return generator.Generate()
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return generator
+	}
+
+	t.Run("Retries flaky handler", func(t *testing.T) {
+		t.Parallel()
+
+		generator := flakyGenerator(t, 3, 2)
+		for i := 0; i < 10; i++ {
+			s, err := generator.GenerateE()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(s) != len("id-123") {
+				t.Fatal("wrong output:", s)
+			}
+		}
+	})
+
+	t.Run("Fails after exhausting retries", func(t *testing.T) {
+		t.Parallel()
+
+		generator := flakyGenerator(t, 1, 2)
+		if _, err := generator.GenerateE(); !errors.Is(err, ErrInvalidOutput) {
+			t.Fatal("err should be ErrInvalidOutput:", err)
+		}
+	})
+
+	t.Run("Requires ValidateOutput", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewGenerator(`a`, &GeneratorArgs{RetryUntilValid: 3}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+		if _, err := NewGenerator(`a`, &GeneratorArgs{ValidateOutput: true, RetryUntilValid: -1}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
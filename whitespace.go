@@ -0,0 +1,59 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WhitespacePolicy is a transformation applied to whitespace in generated strings.
+// Policies other than AsIs may make generated strings no longer match the expression they were generated from.
+type WhitespacePolicy int
+
+const (
+	// AsIs leaves whitespace unchanged. This is the default.
+	AsIs WhitespacePolicy = iota
+	// SingleSpace replaces each run of whitespace with a single space.
+	SingleSpace
+	// NoLeadingTrailing removes leading and trailing whitespace.
+	NoLeadingTrailing
+)
+
+func (policy WhitespacePolicy) apply(s string) string {
+	switch policy {
+	case SingleSpace:
+		var result strings.Builder
+		inSpace := false
+		for _, r := range s {
+			if unicode.IsSpace(r) {
+				if !inSpace {
+					result.WriteByte(' ')
+				}
+				inSpace = true
+				continue
+			}
+			inSpace = false
+			result.WriteRune(r)
+		}
+		return result.String()
+	case NoLeadingTrailing:
+		return strings.TrimSpace(s)
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestWhitespacePolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("AsIs", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`  a {2}b\t\n`, &GeneratorArgs{Flags: syntax.Perl})
+		if s := generator.Generate(); s != "  a  b\t\n" {
+			t.Fatalf("wrong output: %q", s)
+		}
+	})
+
+	t.Run("SingleSpace", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`  a {2}b\t\n`, &GeneratorArgs{
+			Flags:            syntax.Perl,
+			WhitespacePolicy: SingleSpace,
+		})
+		if s := generator.Generate(); s != " a b " {
+			t.Fatalf("wrong output: %q", s)
+		}
+	})
+
+	t.Run("NoLeadingTrailing", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`  a {2}b\t\n`, &GeneratorArgs{
+			Flags:            syntax.Perl,
+			WhitespacePolicy: NoLeadingTrailing,
+		})
+		if s := generator.Generate(); s != "a  b" {
+			t.Fatalf("wrong output: %q", s)
+		}
+	})
+
+	t.Run("Rejects unknown policies", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := NewGenerator("a", &GeneratorArgs{WhitespacePolicy: WhitespacePolicy(-1)}); err == nil {
+			t.Fatal("err should not be nil")
+		}
+	})
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"bufio"
+	"io"
+)
+
+/*
+WriteTo generates a string and writes it to w, returning the number of bytes written and the first error
+returned by w.
+
+Output is written through a buffer as it is generated, so e.g. `a{100000}` or a large MaxUnboundedRepeatCount
+don't build the whole string in memory first. Parts of the output that need to be seen whole are still built
+before they are written: capture groups (to pass to the CaptureGroupHandler), and everything when
+WhitespacePolicy, ChecksumScheme or OnRune are set, or when strings are checked against assertions or
+lengths and regenerated.
+*/
+func (gen *internalGenerator) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+
+	state := gen.newState()
+	state.out = buffered
+	gen.GenerateFunc(state)
+
+	err := buffered.Flush()
+	return counter.n, err
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2014 Zachary Klippenstein
+Copyright 2021 Karel Bilek
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package regen
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"regexp"
+	"regexp/syntax"
+	"testing"
+)
+
+// limitedWriter accepts limit bytes, then fails.
+type limitedWriter struct {
+	limit  int
+	writes int
+}
+
+var errWriterFull = errors.New("writer full")
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, errWriterFull
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestWriteTo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Writes matching strings", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator(`[a-c]{2,5}(x|[0-9]+)`, nil)
+		pattern := regexp.MustCompile(`^[a-c]{2,5}(x|[0-9]+)$`)
+		for i := 0; i < SampleSize; i++ {
+			var b bytes.Buffer
+			n, err := generator.WriteTo(&b)
+			if err != nil {
+				t.Fatal("err should be nil:", err)
+			}
+			if n != int64(b.Len()) {
+				t.Fatalf("returned %d bytes, wrote %d", n, b.Len())
+			}
+			if !pattern.MatchString(b.String()) {
+				t.Fatalf("%q does not match", b.String())
+			}
+		}
+	})
+
+	t.Run("Writes the same as Generate", func(t *testing.T) {
+		t.Parallel()
+
+		pattern := `(\w+)-\d{3}[^a]?`
+		generating, _ := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, RngSource: rand.NewSource(7), WhitespacePolicy: NoLeadingTrailing})
+		writing, _ := NewGenerator(pattern, &GeneratorArgs{Flags: syntax.Perl, RngSource: rand.NewSource(7), WhitespacePolicy: NoLeadingTrailing})
+		for i := 0; i < SampleSize; i++ {
+			var b bytes.Buffer
+			writing.WriteTo(&b)
+			if expected := generating.Generate(); b.String() != expected {
+				t.Fatalf("should be equal: %q, %q", b.String(), expected)
+			}
+		}
+	})
+
+	t.Run("Writes long output incrementally", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("a*", &GeneratorArgs{
+			MinUnboundedRepeatCount: 100000,
+			MaxUnboundedRepeatCount: 100000,
+		})
+		w := &limitedWriter{limit: 1 << 30}
+		n, err := generator.WriteTo(w)
+		if err != nil {
+			t.Fatal("err should be nil:", err)
+		}
+		if n != 100000 {
+			t.Fatal("should be equal:", n, 100000)
+		}
+		if w.writes < 2 {
+			t.Fatal("should write in several parts, wrote in", w.writes)
+		}
+	})
+
+	t.Run("Returns writer error", func(t *testing.T) {
+		t.Parallel()
+
+		generator, _ := NewGenerator("a*", &GeneratorArgs{
+			MinUnboundedRepeatCount: 100000,
+			MaxUnboundedRepeatCount: 100000,
+		})
+		n, err := generator.WriteTo(&limitedWriter{limit: 5000})
+		if !errors.Is(err, errWriterFull) {
+			t.Fatal("should be equal:", err, errWriterFull)
+		}
+		if n != 5000 {
+			t.Fatal("should be equal:", n, 5000)
+		}
+	})
+}